@@ -5,17 +5,25 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+
+	defaultConnectAttempts = 5
+	defaultConnectDelay    = time.Second
+)
+
 var DB *gorm.DB
 
 func ConnectToDB() {
-	user, password, database := os.Getenv("MYSQL_USER"), os.Getenv("MYSQL_PASSWORD"), os.Getenv("MYSQL_DATABASE")
-	dsn := fmt.Sprintf("%s:%s@tcp(localhost:3306)/%s?charset=utf8mb4&parseTime=True&loc=Local", user, password, database)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := ConnectWithRetry(defaultConnectAttempts, defaultConnectDelay)
 	if err != nil {
 		log.Fatal("Cannot connect to database", err.Error())
 	}
@@ -35,3 +43,78 @@ func ConnectToDB() {
 	fmt.Println("Connected to database")
 
 }
+
+// ConnectWithRetry opens and pings the database selected by DB_DRIVER,
+// trying up to maxAttempts times. It waits delay after the first failure and
+// doubles the wait after each further one, which covers the database
+// container still starting up.
+func ConnectWithRetry(maxAttempts int, delay time.Duration) (*gorm.DB, error) {
+	driver := os.Getenv("DB_DRIVER")
+	if driver == "" {
+		driver = DriverMySQL
+	}
+	dsn, err := BuildDSN(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var db *gorm.DB
+		db, err = open(driver, dsn)
+		if err == nil {
+			return db, nil
+		}
+		if attempt < maxAttempts {
+			log.Printf("Database connection attempt %d/%d failed: %s; retrying in %s", attempt, maxAttempts, err, delay)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", maxAttempts, err)
+}
+
+// BuildDSN builds the connection string for the given driver from the
+// environment. MySQL reads MYSQL_USER, MYSQL_PASSWORD and MYSQL_DATABASE;
+// SQLite reads SQLITE_PATH and falls back to a shared in-memory database.
+func BuildDSN(driver string) (string, error) {
+	switch driver {
+	case DriverMySQL:
+		user, password, database := os.Getenv("MYSQL_USER"), os.Getenv("MYSQL_PASSWORD"), os.Getenv("MYSQL_DATABASE")
+		return fmt.Sprintf("%s:%s@tcp(localhost:3306)/%s?charset=utf8mb4&parseTime=True&loc=Local", user, password, database), nil
+	case DriverSQLite:
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" || path == ":memory:" {
+			// A plain ":memory:" database is private to each pooled
+			// connection, so share the cache to keep them consistent.
+			return "file::memory:?cache=shared", nil
+		}
+		return path, nil
+	default:
+		return "", fmt.Errorf("unsupported DB_DRIVER %q", driver)
+	}
+}
+
+func dialector(driver, dsn string) gorm.Dialector {
+	if driver == DriverSQLite {
+		return sqlite.Open(dsn)
+	}
+	return mysql.Open(dsn)
+}
+
+func open(driver, dsn string) (*gorm.DB, error) {
+	// TranslateError maps driver-specific errors such as unique violations
+	// to GORM sentinels like gorm.ErrDuplicatedKey.
+	db, err := gorm.Open(dialector(driver, dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+	connection, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := connection.Ping(); err != nil {
+		connection.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"bytes"
+	"felix1234567890/go-trello/models"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectWithRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	// SQLite cannot create a database file inside a missing directory, so
+	// every ping fails.
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "missing", "app.db"))
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	db, err := ConnectWithRetry(3, time.Millisecond)
+
+	assert.Nil(t, db)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "giving up after 3 attempts")
+	// Every attempt but the last logs before sleeping.
+	assert.Equal(t, 2, strings.Count(logs.String(), "Database connection attempt"))
+	assert.Contains(t, logs.String(), "attempt 1/3")
+	assert.Contains(t, logs.String(), "attempt 2/3")
+}
+
+func TestConnectWithRetrySucceedsFirstTime(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "app.db"))
+
+	db, err := ConnectWithRetry(3, time.Millisecond)
+	require.NoError(t, err)
+	connection, err := db.DB()
+	require.NoError(t, err)
+	assert.NoError(t, connection.Ping())
+	connection.Close()
+}
+
+func TestConnectToDBWithSQLite(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "app.db"))
+	previous := DB
+	t.Cleanup(func() { DB = previous })
+
+	ConnectToDB()
+
+	require.NotNil(t, DB)
+	assert.Equal(t, DriverSQLite, DB.Dialector.Name())
+	assert.True(t, DB.Migrator().HasTable(&models.User{}))
+	require.NoError(t, DB.Create(&models.User{Username: "alice", Email: "alice@example.com"}).Error)
+	connection, err := DB.DB()
+	require.NoError(t, err)
+	connection.Close()
+}
+
+func TestBuildDSN(t *testing.T) {
+	t.Setenv("MYSQL_USER", "user")
+	t.Setenv("MYSQL_PASSWORD", "secret")
+	t.Setenv("MYSQL_DATABASE", "trello")
+	dsn, err := BuildDSN(DriverMySQL)
+	require.NoError(t, err)
+	assert.Equal(t, "user:secret@tcp(localhost:3306)/trello?charset=utf8mb4&parseTime=True&loc=Local", dsn)
+
+	for path, want := range map[string]string{
+		"":            "file::memory:?cache=shared",
+		":memory:":    "file::memory:?cache=shared",
+		"/tmp/app.db": "/tmp/app.db",
+	} {
+		t.Setenv("SQLITE_PATH", path)
+		dsn, err := BuildDSN(DriverSQLite)
+		require.NoError(t, err)
+		assert.Equal(t, want, dsn)
+	}
+
+	_, err = BuildDSN("postgres")
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"felix1234567890/go-trello/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthHandler handles HTTP requests related to authentication tokens.
+type AuthHandler struct{}
+
+// NewAuthHandler creates a new AuthHandler instance.
+func NewAuthHandler() *AuthHandler {
+	return &AuthHandler{}
+}
+
+// Validate godoc
+//
+//	@Summary		Validate a token
+//	@Description	Check that the bearer token is valid without performing any action
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	fiber.Map	"Token is valid"
+//	@Failure		401	{object}	fiber.Map	"Token is missing, invalid or expired"
+//	@Router			/auth/validate [get]
+func (h *AuthHandler) Validate(c *fiber.Ctx) error {
+	user := c.Locals("user").(models.User)
+	response := fiber.Map{
+		"valid":   true,
+		"user_id": user.ID,
+	}
+	if expiresAt, ok := c.Locals("token_expires_at").(time.Time); ok {
+		response["expires_at"] = expiresAt
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
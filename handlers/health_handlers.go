@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// HealthHandler handles liveness and readiness probes.
+type HealthHandler struct {
+	DB *gorm.DB
+}
+
+// NewHealthHandler creates a new HealthHandler instance.
+func NewHealthHandler(db *gorm.DB) *HealthHandler {
+	return &HealthHandler{
+		DB: db,
+	}
+}
+
+// Health godoc
+//
+//	@Summary		Liveness probe
+//	@Description	Report that the server process is up
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Server is alive"
+//	@Router			/health [get]
+func (h *HealthHandler) Health(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "ok"})
+}
+
+// Ready godoc
+//
+//	@Summary		Readiness probe
+//	@Description	Report whether the server can reach its database
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Database is reachable"
+//	@Failure		503	{object}	fiber.Map	"Database is unavailable"
+//	@Router			/ready [get]
+func (h *HealthHandler) Ready(c *fiber.Ctx) error {
+	if h.DB == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "unavailable"})
+	}
+	connection, err := h.DB.DB()
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "unavailable"})
+	}
+	if err := connection.Ping(); err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "unavailable"})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "ok"})
+}
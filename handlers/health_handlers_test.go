@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"felix1234567890/go-trello/testutil"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHealthApp(t *testing.T) (*fiber.App, *HealthHandler) {
+	t.Helper()
+	healthHandler := NewHealthHandler(testutil.NewDB(t))
+	app := fiber.New()
+	app.Get("/health", healthHandler.Health)
+	app.Get("/ready", healthHandler.Ready)
+	return app, healthHandler
+}
+
+func TestHealth(t *testing.T) {
+	app, _ := newHealthApp(t)
+
+	status, body := doGet(t, app, "/health")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "ok", body["status"])
+}
+
+func TestReadyWhenConnected(t *testing.T) {
+	app, _ := newHealthApp(t)
+
+	status, body := doGet(t, app, "/ready")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "ok", body["status"])
+}
+
+func TestReadyWhenDatabaseIsDown(t *testing.T) {
+	app, healthHandler := newHealthApp(t)
+	connection, err := healthHandler.DB.DB()
+	require.NoError(t, err)
+	require.NoError(t, connection.Close())
+
+	status, body := doGet(t, app, "/ready")
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+	assert.Equal(t, "unavailable", body["status"])
+}
+
+func TestReadyWithoutDatabase(t *testing.T) {
+	app := fiber.New()
+	app.Get("/ready", NewHealthHandler(nil).Ready)
+
+	status, _ := doGet(t, app, "/ready")
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"felix1234567890/go-trello/testutil"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Run(m)
+}
+
+// doGet sends a GET request to app and decodes the JSON response body.
+func doGet(t *testing.T, app *fiber.App, path string) (int, map[string]interface{}) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	return resp.StatusCode, decoded
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"felix1234567890/go-trello/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MetricsHandler exposes the request metrics collected by middlewares.Metrics.
+type MetricsHandler struct{}
+
+// NewMetricsHandler creates a new MetricsHandler instance.
+func NewMetricsHandler() *MetricsHandler {
+	return &MetricsHandler{}
+}
+
+// Metrics godoc
+//
+//	@Summary		Request metrics
+//	@Description	Get per-route request counts, status codes and latency histograms
+//	@Tags			status
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Per-route request metrics"
+//	@Router			/metrics [get]
+func (h *MetricsHandler) Metrics(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"routes": middlewares.MetricsSnapshot(),
+	})
+}
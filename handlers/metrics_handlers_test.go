@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"felix1234567890/go-trello/middlewares"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrapeRoute fetches /metrics from app and returns the entry for GET path.
+func scrapeRoute(t *testing.T, app *fiber.App, path string) middlewares.RouteMetrics {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var body struct {
+		Routes []middlewares.RouteMetrics `json:"routes"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	for _, route := range body.Routes {
+		if route.Method == fiber.MethodGet && route.Path == path {
+			return route
+		}
+	}
+	return middlewares.RouteMetrics{}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	app := fiber.New()
+	app.Use(middlewares.Metrics())
+	app.Get("/metrics", NewMetricsHandler().Metrics)
+	app.Get("/metrics-handler-test", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	// The counters are package-level, so compare against what earlier runs
+	// left behind.
+	before := scrapeRoute(t, app, "/metrics-handler-test")
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics-handler-test", nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	route := scrapeRoute(t, app, "/metrics-handler-test")
+	assert.Equal(t, before.Count+1, route.Count)
+	assert.Equal(t, before.StatusCodes[fiber.StatusNoContent]+1, route.StatusCodes[fiber.StatusNoContent])
+	assert.Equal(t, before.Latency.Count+1, route.Latency.Count)
+	assert.Greater(t, route.Latency.Sum, before.Latency.Sum)
+	require.Len(t, route.Latency.Buckets, 11)
+	assert.Equal(t, 0.005, route.Latency.Buckets[0].Le)
+	assert.Equal(t, 10.0, route.Latency.Buckets[10].Le)
+	for i := 1; i < len(route.Latency.Buckets); i++ {
+		assert.Greater(t, route.Latency.Buckets[i].Le, route.Latency.Buckets[i-1].Le)
+		assert.GreaterOrEqual(t, route.Latency.Buckets[i].Count, route.Latency.Buckets[i-1].Count, "buckets should be cumulative")
+	}
+	assert.Equal(t, route.Count, route.Latency.Buckets[10].Count)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"felix1234567890/go-trello/service"
+	"felix1234567890/go-trello/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultRegistrationDays = 30
+	maxRegistrationDays     = 365
+)
+
+// StatsHandler handles HTTP requests for aggregate statistics.
+type StatsHandler struct {
+	StatsService service.StatsService
+}
+
+// NewStatsHandler creates a new StatsHandler instance.
+func NewStatsHandler(statsService service.StatsService) *StatsHandler {
+	return &StatsHandler{
+		StatsService: statsService,
+	}
+}
+
+// GetTotals godoc
+//
+//	@Summary		Totals
+//	@Description	Get the total number of users, excluding deleted ones
+//	@Tags			stats
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	models.Totals	"Current totals"
+//	@Failure		401	{object}	fiber.Map		"Not logged in"
+//	@Failure		403	{object}	fiber.Map		"Admin privileges required"
+//	@Failure		500	{object}	fiber.Map		"Internal Server Error"
+//	@Router			/stats [get]
+func (h *StatsHandler) GetTotals(c *fiber.Ctx) error {
+	totals, err := h.StatsService.GetTotals(c.UserContext())
+	if err != nil {
+		return utils.HandleInternalError(c, err)
+	}
+	return utils.JsonResponse(c, fiber.StatusOK, totals)
+}
+
+// GetRegistrations godoc
+//
+//	@Summary		Daily registrations
+//	@Description	Get the number of new users per day over the last N days
+//	@Tags			stats
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			days	query		int							false	"Window size in days (1-365, default 30)"
+//	@Success		200		{object}	fiber.Map					"Daily registration counts"
+//	@Failure		400		{object}	fiber.Map					"Invalid days parameter"
+//	@Failure		401		{object}	fiber.Map					"Not logged in"
+//	@Failure		403		{object}	fiber.Map					"Admin privileges required"
+//	@Failure		500		{object}	fiber.Map					"Internal Server Error"
+//	@Router			/stats/registrations [get]
+func (h *StatsHandler) GetRegistrations(c *fiber.Ctx) error {
+	days := c.QueryInt("days", defaultRegistrationDays)
+	if days < 1 || days > maxRegistrationDays {
+		return utils.HandleErrorResponseWithCode(c, fiber.StatusBadRequest, utils.ErrCodeBadRequest, "days must be between 1 and 365")
+	}
+	series, err := h.StatsService.GetDailyRegistrations(c.UserContext(), days)
+	if err != nil {
+		return utils.HandleInternalError(c, err)
+	}
+	return utils.JsonResponse(c, fiber.StatusOK, fiber.Map{
+		"registrations": series,
+	})
+}
+
+// GetThisMonth godoc
+//
+//	@Summary		This month's counts
+//	@Description	Get the number of rows created in the current calendar month
+//	@Tags			stats
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	models.PeriodCounts	"Counts for the current month"
+//	@Failure		401	{object}	fiber.Map			"Not logged in"
+//	@Failure		403	{object}	fiber.Map			"Admin privileges required"
+//	@Failure		500	{object}	fiber.Map			"Internal Server Error"
+//	@Router			/stats/this-month [get]
+func (h *StatsHandler) GetThisMonth(c *fiber.Ctx) error {
+	counts, err := h.StatsService.GetThisMonth(c.UserContext())
+	if err != nil {
+		return utils.HandleInternalError(c, err)
+	}
+	return utils.JsonResponse(c, fiber.StatusOK, counts)
+}
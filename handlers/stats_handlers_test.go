@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"felix1234567890/go-trello/repository"
+	"felix1234567890/go-trello/service"
+	"felix1234567890/go-trello/testutil"
+	"felix1234567890/go-trello/utils"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsErrorsCarryCodes(t *testing.T) {
+	db := testutil.NewDB(t)
+	userRepository := repository.NewUserRepository(db)
+	statsHandler := NewStatsHandler(service.NewStatsService(userRepository))
+	userHandler := NewUserHandler(service.NewUserService(userRepository))
+	app := fiber.New()
+	app.Get("/stats", statsHandler.GetTotals)
+	app.Get("/stats/registrations", statsHandler.GetRegistrations)
+	app.Get("/users", userHandler.GetUsers)
+
+	status, body := doGet(t, app, "/stats/registrations?days=0")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Equal(t, map[string]interface{}{"code": utils.ErrCodeBadRequest, "message": "days must be between 1 and 365"}, body["error"])
+
+	// Database failures are reported without the driver's error text.
+	connection, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, connection.Close())
+	for _, path := range []string{"/stats", "/stats/registrations", "/users"} {
+		status, body = doGet(t, app, path)
+		assert.Equal(t, fiber.StatusInternalServerError, status, path)
+		assert.Equal(t, map[string]interface{}{"code": utils.ErrCodeInternal, "message": "internal server error"}, body["error"], path)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StatusHandler reports process uptime and runtime statistics.
+type StatusHandler struct {
+	StartedAt time.Time
+}
+
+// NewStatusHandler creates a new StatusHandler for a process started at startedAt.
+func NewStatusHandler(startedAt time.Time) *StatusHandler {
+	return &StatusHandler{
+		StartedAt: startedAt,
+	}
+}
+
+// Status godoc
+//
+//	@Summary		Server status
+//	@Description	Get server uptime and basic runtime statistics
+//	@Tags			status
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	fiber.Map	"Uptime and runtime statistics"
+//	@Failure		401	{object}	fiber.Map	"Not logged in"
+//	@Failure		403	{object}	fiber.Map	"Admin privileges required"
+//	@Router			/status [get]
+func (h *StatusHandler) Status(c *fiber.Ctx) error {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"uptime_seconds": int64(time.Since(h.StartedAt).Seconds()),
+		"goroutines":     runtime.NumGoroutine(),
+		"alloc_bytes":    mem.Alloc,
+		"num_cpu":        runtime.NumCPU(),
+	})
+}
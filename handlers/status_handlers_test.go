@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	app := fiber.New()
+	app.Get("/status", NewStatusHandler(time.Now()).Status)
+
+	status, body := doGet(t, app, "/status")
+	require.Equal(t, fiber.StatusOK, status)
+	for _, field := range []string{"uptime_seconds", "goroutines", "alloc_bytes", "num_cpu"} {
+		assert.Contains(t, body, field)
+	}
+	assert.GreaterOrEqual(t, body["uptime_seconds"].(float64), float64(0))
+	assert.Greater(t, body["goroutines"].(float64), float64(0))
+}
@@ -3,9 +3,13 @@ package handlers
 
 import (
 	"errors"
+	"felix1234567890/go-trello/mailer"
 	"felix1234567890/go-trello/models"
 	"felix1234567890/go-trello/service"
 	"felix1234567890/go-trello/utils"
+	"log"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -14,92 +18,141 @@ import (
 // UserHandler handles HTTP requests related to users.
 type UserHandler struct {
 	UserService service.UserService
+	// Mailer sends the verification email after signup.
+	Mailer mailer.Mailer
 }
 
 // NewUserHandler creates a new UserHandler instance.
 func NewUserHandler(userService service.UserService) *UserHandler {
 	return &UserHandler{
 		UserService: userService,
+		Mailer:      mailer.Noop{},
 	}
 }
 
 // GetUsers godoc
 //
 //	@Summary		Get all users
-//	@Description	Get a list of all users
+//	@Description	Get a list of all users. Admins also see each user's last login time.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}		models.User	"List of users"
-//	@Failure		500	{object}	fiber.Map	"Internal Server Error"
+//	@Success		200	{array}		models.UserResponse	"List of users"
+//	@Failure		500	{object}	fiber.Map			"Internal Server Error"
 //	@Router			/users [get]
 func (h *UserHandler) GetUsers(c *fiber.Ctx) error {
-	users, err := h.UserService.GetUsers()
+	users, err := h.UserService.GetUsers(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
-			"message": err.Error(),
-		})
+		return utils.HandleInternalError(c, err)
 	}
 	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
-		"users": users,
+		"users": userResponses(c, users),
 	})
 }
 
+// SearchUsers godoc
+//
+//	@Summary		Search users
+//	@Description	Search users by username, email or bio, case-insensitively. Admins also see each user's last login time.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			q			query		string		true	"Search term"
+//	@Param			page		query		int			false	"Page number"
+//	@Param			pageSize	query		int			false	"Page size"
+//	@Success		200			{object}	fiber.Map	"Paginated matching users"
+//	@Failure		400			{object}	fiber.Map	"Missing search term"
+//	@Failure		500			{object}	fiber.Map	"Internal Server Error"
+//	@Router			/users/search [get]
+func (h *UserHandler) SearchUsers(c *fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		return utils.HandleErrorResponseWithCode(c, fiber.StatusBadRequest, utils.ErrCodeBadRequest, "Query parameter q is required")
+	}
+	page, pageSize := utils.ParsePagination(c)
+	users, total, err := h.UserService.SearchUsers(c.UserContext(), q, page, pageSize)
+	if err != nil {
+		return utils.HandleInternalError(c, err)
+	}
+	response := utils.Paginate(userResponses(c, users), total, page, pageSize)
+	response["links"] = utils.PaginationLinks(c, page, utils.TotalPages(total, pageSize))
+	return utils.JsonResponse(c, fiber.StatusOK, response)
+}
+
 // GetUserById godoc
 //
 //	@Summary		Get a user by ID
-//	@Description	Get details of a specific user
+//	@Description	Get details of a specific user. Admins also see the user's last login time.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		string		true	"User ID"
-//	@Success		200	{object}	models.User	"User details"
-//	@Failure		404	{object}	fiber.Map	"User not found"
-//	@Failure		500	{object}	fiber.Map	"Internal Server Error"
+//	@Param			id				path		string				true	"User ID"
+//	@Param			includeDeleted	query		bool				false	"Also return soft-deleted users (admins only)"
+//	@Success		200				{object}	models.UserResponse	"User details"
+//	@Failure		401				{object}	fiber.Map			"Not logged in"
+//	@Failure		403				{object}	fiber.Map			"Admin privileges required"
+//	@Failure		404				{object}	fiber.Map			"User not found"
+//	@Failure		500				{object}	fiber.Map			"Internal Server Error"
 //	@Router			/users/{id} [get]
 func (h *UserHandler) GetUserById(c *fiber.Ctx) error {
 	id := c.Params("id")
-	user, err := h.UserService.GetUserById(id)
+	user, err := h.UserService.GetUserById(c.UserContext(), id, c.QueryBool("includeDeleted"))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
-				"message": "User with an id " + id + " was not found",
-			})
+			return utils.HandleErrorResponseWithCode(c, fiber.StatusNotFound, utils.ErrCodeUserNotFound, "User with an id "+id+" was not found")
 		}
-
-		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
-			"message": err.Error(),
-		})
+		return utils.HandleInternalError(c, err)
 	}
 	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
-		"user": user,
+		"user": userResponse(c, user),
 	})
 }
 
 // DeleteUser godoc
 //
 //	@Summary		Delete a user
-//	@Description	Delete a user by ID
+//	@Description	Delete a user by ID. Users may delete themselves; admins may delete anyone.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
+//	@Security		ApiKeyAuth
 //	@Param			id	path		string		true	"User ID"
 //	@Success		200	{object}	fiber.Map	"User deleted successfully"
+//	@Failure		401	{object}	fiber.Map	"Not logged in"
+//	@Failure		403	{object}	fiber.Map	"Not the target user or an admin"
 //	@Failure		404	{object}	fiber.Map	"User not found"
 //	@Failure		500	{object}	fiber.Map	"Internal Server Error"
 //	@Router			/users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
-	id := c.Params("id")
-	err := h.UserService.DeleteUser(id)
+	return h.deleteUser(c, c.Params("id"))
+}
+
+// DeleteMe godoc
+//
+//	@Summary		Delete current user
+//	@Description	Delete the authenticated user's own account
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	fiber.Map	"User deleted successfully"
+//	@Failure		401	{object}	fiber.Map	"Not logged in"
+//	@Failure		500	{object}	fiber.Map	"Internal Server Error"
+//	@Router			/users/me [delete]
+func (h *UserHandler) DeleteMe(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+	return h.deleteUser(c, strconv.FormatUint(uint64(userID), 10))
+}
+
+// deleteUser soft-deletes the user with the given id. Tokens issued to
+// them stop working because the auth middlewares skip deleted users.
+func (h *UserHandler) deleteUser(c *fiber.Ctx, id string) error {
+	err := h.UserService.DeleteUser(c.UserContext(), id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
-				"message": "User with an id " + id + " was not found",
-			})
+			return utils.HandleErrorResponseWithCode(c, fiber.StatusNotFound, utils.ErrCodeUserNotFound, "User with an id "+id+" was not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
-			"message": err.Error(),
-		})
+		return utils.HandleInternalError(c, err)
 	}
 	return c.Status(fiber.StatusOK).JSON(&fiber.Map{
 		"message": "User deleted successfully",
@@ -109,46 +162,68 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 // UpdateUser godoc
 //
 //	@Summary		Update a user
-//	@Description	Update a user's details
+//	@Description	Update a user's details. Users may update themselves; admins may update anyone.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
+//	@Security		ApiKeyAuth
 //	@Param			id		path		string						true	"User ID"
 //	@Param			user	body		models.UpdateUserRequest	true	"User details to update"
 //	@Success		200		{object}	fiber.Map					"User updated successfully"
 //	@Failure		400		{object}	fiber.Map					"Invalid request body or validation errors"
+//	@Failure		401		{object}	fiber.Map					"Not logged in"
+//	@Failure		403		{object}	fiber.Map					"Not the target user or an admin"
 //	@Failure		404		{object}	fiber.Map					"User not found"
+//	@Failure		409		{object}	fiber.Map					"Email already in use"
 //	@Failure		500		{object}	fiber.Map					"Internal Server Error"
 //	@Router			/users/{id} [put]
 func (h *UserHandler) UpdateUser(ctx *fiber.Ctx) error {
+	return h.updateUser(ctx, ctx.Params("id"))
+}
+
+// UpdateMe godoc
+//
+//	@Summary		Update current user
+//	@Description	Update the authenticated user's own details
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			user	body		models.UpdateUserRequest	true	"User details to update"
+//	@Success		200		{object}	fiber.Map					"User updated successfully"
+//	@Failure		400		{object}	fiber.Map					"Invalid request body or validation errors"
+//	@Failure		401		{object}	fiber.Map					"Not logged in"
+//	@Failure		409		{object}	fiber.Map					"Email already in use"
+//	@Failure		500		{object}	fiber.Map					"Internal Server Error"
+//	@Router			/users/me [put]
+func (h *UserHandler) UpdateMe(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	return h.updateUser(ctx, strconv.FormatUint(uint64(userID), 10))
+}
+
+// updateUser validates the request body and applies it to the user with
+// the given id.
+func (h *UserHandler) updateUser(ctx *fiber.Ctx, id string) error {
 	var req *models.UpdateUserRequest
-	id := ctx.Params("id")
 	if err := ctx.BodyParser(&req); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Invalid request body",
-		})
+		return utils.HandleErrorResponseWithCode(ctx, fiber.StatusBadRequest, utils.ErrCodeInvalidBody, "Invalid request body")
 	}
 
-	validationErrors := utils.ValidateRequest(req)
-	if len(validationErrors) > 0 {
-		errorMessages := make([]string, len(validationErrors))
-		for i, validationErr := range validationErrors {
-			errorMessages[i] = validationErr.Error()
-		}
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"errors": errorMessages,
-		})
+	if validationErrors := utils.ValidateRequest(req); len(validationErrors) > 0 {
+		return utils.HandleValidationErrors(ctx, validationErrors)
 	}
-	err := h.UserService.UpdateUser(id, req)
+	err := h.UserService.UpdateUser(ctx.UserContext(), id, req)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ctx.Status(fiber.StatusNotFound).JSON(&fiber.Map{
-				"message": "User with an id " + id + " could not be updated",
-			})
+			return utils.HandleErrorResponseWithCode(ctx, fiber.StatusNotFound, utils.ErrCodeUserNotFound, "User with an id "+id+" could not be updated")
+		}
+		if errors.Is(err, utils.ErrUserAlreadyExists) {
+			return utils.HandleErrorResponseWithCode(ctx, fiber.StatusConflict, utils.ErrCodeConflict, "Email already in use")
+		}
+		if errors.Is(err, utils.ErrPasswordTooLong) {
+			return utils.HandleErrorResponseWithCode(ctx, fiber.StatusBadRequest, utils.ErrCodeValidation, err.Error())
 		}
-		return ctx.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
-			"message": err.Error(),
-		})
+		return utils.HandleInternalError(ctx, err)
 	}
 	return ctx.Status(fiber.StatusOK).JSON(&fiber.Map{
 		"message": "User updated successfully",
@@ -165,32 +240,29 @@ func (h *UserHandler) UpdateUser(ctx *fiber.Ctx) error {
 //	@Param			user	body		models.CreateUserRequest	true	"User details"
 //	@Success		201		{object}	fiber.Map					"User created successfully, token returned"
 //	@Failure		400		{object}	fiber.Map					"Invalid request body or validation errors"
+//	@Failure		409		{object}	fiber.Map					"Email already in use"
 //	@Failure		500		{object}	fiber.Map					"Internal Server Error"
 //	@Router			/users [post]
 func (h *UserHandler) CreateUser(ctx *fiber.Ctx) error {
 	var req *models.CreateUserRequest
 	if err := ctx.BodyParser(&req); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Invalid request body",
-		})
+		return utils.HandleErrorResponseWithCode(ctx, fiber.StatusBadRequest, utils.ErrCodeInvalidBody, "Invalid request body")
 	}
-	validationErrors := utils.ValidateRequest(req)
-
-	if len(validationErrors) > 0 {
-		errorMessages := make([]string, len(validationErrors))
-		for i, validationErr := range validationErrors {
-			errorMessages[i] = validationErr.Error()
-		}
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"errors": errorMessages,
-		})
-
+	if validationErrors := utils.ValidateRequest(req); len(validationErrors) > 0 {
+		return utils.HandleValidationErrors(ctx, validationErrors)
 	}
 	user := req.ToUser()
-	id, err := h.UserService.CreateUser(user)
+	id, err := h.UserService.CreateUser(ctx.UserContext(), user)
 	if err != nil {
-		return utils.HandleErrorResponse(ctx, fiber.StatusInternalServerError, err.Error())
+		if errors.Is(err, utils.ErrUserAlreadyExists) {
+			return utils.HandleErrorResponseWithCode(ctx, fiber.StatusConflict, utils.ErrCodeConflict, "Email already in use")
+		}
+		if errors.Is(err, utils.ErrPasswordTooLong) {
+			return utils.HandleErrorResponseWithCode(ctx, fiber.StatusBadRequest, utils.ErrCodeValidation, err.Error())
+		}
+		return utils.HandleInternalError(ctx, err)
 	}
+	h.sendVerificationEmail(id, user.Email)
 	token, err := utils.CreateToken(id)
 	return utils.JsonResponse(ctx, fiber.StatusCreated, fiber.Map{
 		"token": token,
@@ -207,28 +279,27 @@ func (h *UserHandler) CreateUser(ctx *fiber.Ctx) error {
 //	@Param			credentials	body		models.LoginUserRequest	true	"Login credentials"
 //	@Success		200			{object}	fiber.Map				"Authentication successful, token returned"
 //	@Failure		400			{object}	fiber.Map				"Invalid request body or validation errors"
+//	@Failure		401			{object}	fiber.Map				"Invalid email or password"
+//	@Failure		403			{object}	fiber.Map				"Email address not verified"
 //	@Failure		500			{object}	fiber.Map				"Internal Server Error"
 //	@Router			/login [post]
 func (h *UserHandler) Login(ctx *fiber.Ctx) error {
 	var req *models.LoginUserRequest
 	if err := ctx.BodyParser(&req); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Invalid request body",
-		})
-	}
-	validationErrors := utils.ValidateRequest(req)
-	if len(validationErrors) > 0 {
-		errorMessages := make([]string, len(validationErrors))
-		for i, validationErr := range validationErrors {
-			errorMessages[i] = validationErr.Error()
-		}
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"errors": errorMessages,
-		})
+		return utils.HandleErrorResponseWithCode(ctx, fiber.StatusBadRequest, utils.ErrCodeInvalidBody, "Invalid request body")
+	}
+	if validationErrors := utils.ValidateRequest(req); len(validationErrors) > 0 {
+		return utils.HandleValidationErrors(ctx, validationErrors)
 	}
-	id, err := h.UserService.LoginUser(req)
+	id, err := h.UserService.LoginUser(ctx.UserContext(), req)
 	if err != nil {
-		return utils.HandleErrorResponse(ctx, fiber.StatusInternalServerError, err.Error())
+		if errors.Is(err, utils.ErrInvalidCredentials) {
+			return utils.HandleErrorResponseWithCode(ctx, fiber.StatusUnauthorized, utils.ErrCodeUnauthorized, "Invalid email or password")
+		}
+		if errors.Is(err, utils.ErrEmailNotVerified) {
+			return utils.HandleErrorResponseWithCode(ctx, fiber.StatusForbidden, utils.ErrCodeForbidden, "Please verify your email address before logging in")
+		}
+		return utils.HandleInternalError(ctx, err)
 	}
 	token, err := utils.CreateToken(id)
 	return utils.JsonResponse(ctx, fiber.StatusOK, fiber.Map{
@@ -236,6 +307,39 @@ func (h *UserHandler) Login(ctx *fiber.Ctx) error {
 	})
 }
 
+// VerifyEmail godoc
+//
+//	@Summary		Verify email address
+//	@Description	Confirm a user's email address with the token issued at signup
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	query		string		true	"Verification token"
+//	@Success		200		{object}	fiber.Map	"Email verified"
+//	@Failure		400		{object}	fiber.Map	"Missing, invalid or expired token"
+//	@Failure		404		{object}	fiber.Map	"User not found"
+//	@Failure		500		{object}	fiber.Map	"Internal Server Error"
+//	@Router			/users/verify [get]
+func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return utils.HandleErrorResponseWithCode(c, fiber.StatusBadRequest, utils.ErrCodeBadRequest, "Query parameter token is required")
+	}
+	id, err := utils.ParseVerificationToken(token)
+	if err != nil {
+		return utils.HandleErrorResponseWithCode(c, fiber.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid or expired verification token")
+	}
+	if err := h.UserService.VerifyEmail(c.UserContext(), id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.HandleErrorResponseWithCode(c, fiber.StatusNotFound, utils.ErrCodeUserNotFound, "User was not found")
+		}
+		return utils.HandleInternalError(c, err)
+	}
+	return utils.JsonResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Email verified",
+	})
+}
+
 // GetMe godoc
 //
 //	@Summary		Get current user
@@ -248,5 +352,39 @@ func (h *UserHandler) Login(ctx *fiber.Ctx) error {
 //	@Router			/me [get]
 func (h *UserHandler) GetMe(c *fiber.Ctx) error {
 	user := c.Locals("user").(models.User)
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{"data": fiber.Map{"user": user}})
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"data": fiber.Map{"user": user.ToPrivateResponse()}})
+}
+
+// sendVerificationEmail mails user id the link that verifies their email.
+// Only the user ID is logged; the link carries a token that verifies the
+// account for whoever holds it.
+func (h *UserHandler) sendVerificationEmail(id uint, email string) {
+	token, err := utils.CreateVerificationToken(id)
+	if err != nil {
+		log.Printf("Cannot create verification token for user %d: %s", id, err)
+		return
+	}
+	if err := h.Mailer.SendVerificationEmail(email, "/api/users/verify?token="+token); err != nil {
+		log.Printf("Cannot send verification email to user %d: %s", id, err)
+		return
+	}
+	log.Printf("Sent verification email to user %d", id)
+}
+
+// userResponse returns the admin view of user, which includes account
+// activity such as the last login, to admins and the public view to everyone
+// else. Admins are recognised by the admin middlewares.
+func userResponse(c *fiber.Ctx, user models.User) models.UserResponse {
+	if isAdmin, _ := c.Locals("is_admin").(bool); isAdmin {
+		return user.ToPrivateResponse()
+	}
+	return user.ToResponse()
+}
+
+// userResponses is userResponse for a list of users.
+func userResponses(c *fiber.Ctx, users []models.User) []models.UserResponse {
+	if isAdmin, _ := c.Locals("is_admin").(bool); isAdmin {
+		return models.ToPrivateUserResponses(users)
+	}
+	return models.ToUserResponses(users)
 }
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"felix1234567890/go-trello/repository"
+	"felix1234567890/go-trello/service"
+	"felix1234567890/go-trello/testutil"
+	"felix1234567890/go-trello/utils"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestCreateUserMapsInsertTimeUniqueViolationToConflict(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+
+	// The pre-check passes, as it does for both sides of a race, and then
+	// the insert hits the unique index on email.
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `users`").
+		WillReturnError(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'alice@example.com' for key 'users.email'"})
+	mock.ExpectRollback()
+
+	handler := NewUserHandler(service.NewUserService(repository.NewUserRepository(db)))
+	app := fiber.New()
+	app.Post("/users", handler.CreateUser)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/users",
+		strings.NewReader(`{"username":"alice","email":"alice@example.com","password":"Password1!"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode, string(body))
+	assert.Contains(t, string(body), utils.ErrCodeConflict)
+	assert.Contains(t, string(body), "Email already in use")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// recordingMailer keeps the verification emails it is asked to send.
+type recordingMailer struct {
+	to, links []string
+}
+
+func (m *recordingMailer) SendVerificationEmail(to string, link string) error {
+	m.to = append(m.to, to)
+	m.links = append(m.links, link)
+	return nil
+}
+
+func TestCreateUserMailsVerificationLinkWithoutLoggingIt(t *testing.T) {
+	handler := NewUserHandler(service.NewUserService(repository.NewUserRepository(testutil.NewDB(t))))
+	sent := &recordingMailer{}
+	handler.Mailer = sent
+	app := fiber.New()
+	app.Post("/users", handler.CreateUser)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	req := httptest.NewRequest(fiber.MethodPost, "/users",
+		strings.NewReader(`{"username":"alice","email":"Alice@Example.com","password":"password1"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	require.Equal(t, []string{"alice@example.com"}, sent.to)
+	token := strings.TrimPrefix(sent.links[0], "/api/users/verify?token=")
+	id, err := utils.ParseVerificationToken(token)
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), fmt.Sprintf("user %d", id))
+	assert.NotContains(t, logs.String(), token)
+}
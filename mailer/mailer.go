@@ -0,0 +1,18 @@
+// Package mailer sends the emails the application needs, such as the link a
+// new user follows to verify their address.
+package mailer
+
+// Mailer delivers emails. Implementations must not log the links they
+// send, since those carry tokens that act on the recipient's account.
+type Mailer interface {
+	// SendVerificationEmail emails to the link that verifies that address.
+	SendVerificationEmail(to string, link string) error
+}
+
+// Noop discards every email. It stands in until a real sender is configured.
+type Noop struct{}
+
+// SendVerificationEmail discards the email.
+func (Noop) SendVerificationEmail(to string, link string) error {
+	return nil
+}
@@ -1,20 +1,37 @@
 package main
 
 import (
+	"felix1234567890/go-trello/database"
+	"felix1234567890/go-trello/handlers"
+	"felix1234567890/go-trello/middlewares"
 	"felix1234567890/go-trello/routes"
 	"flag"
+	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	_ "felix1234567890/go-trello/docs"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/swagger"
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 )
 
-const defaultPort = "3000"
+const (
+	defaultPort     = "3000"
+	shutdownTimeout = 10 * time.Second
+	defaultBodySize = 1 << 20
+)
 
 // @title			Go-Trello API
 // @version		1.0
@@ -27,20 +44,171 @@ const defaultPort = "3000"
 // @host			localhost:3000
 // @BasePath		/
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run configures and starts the server, blocking until it is shut down by
+// SIGINT/SIGTERM or fails to listen.
+func run() error {
+	startedAt := time.Now()
 	err := godotenv.Load(".env")
 	if err != nil {
-		log.Fatalf("Error loading .env file: %s", err)
+		return fmt.Errorf("error loading .env file: %w", err)
 	}
-	// database.ConnectToDB()
+	// Connect before building handlers and middlewares, which capture
+	// database.DB when they are created.
+	database.ConnectToDB()
 	// utils.FakeUserFactory()
 	port := flag.String("port", defaultPort, "server port")
 	flag.Parse()
-	app := fiber.New()
-	app.Use(logger.New())
-	app.Use(cors.New())
+	app := newApp(startedAt)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- shutdown(app, database.DB, <-signals)
+	}()
+
+	if err := app.Listen(":" + *port); err != nil {
+		return err
+	}
+	return <-shutdownErr
+}
+
+// newApp builds the server with its middlewares and routes. The database
+// must already be connected, since handlers and middlewares capture
+// database.DB.
+func newApp(startedAt time.Time) *fiber.App {
+	// Fiber rejects bodies over BodyLimit with 413 before any handler runs.
+	app := fiber.New(fiber.Config{BodyLimit: bodyLimit()})
+	app.Use(middlewares.RequestID())
+	app.Use(middlewares.Metrics())
+	app.Use(logger.New(logger.Config{
+		Format: "${time} | ${locals:request_id} | ${status} | ${latency} | ${ip} | ${method} | ${path} | ${error}\n",
+	}))
+	app.Use(cors.New(corsConfig()))
+	app.Use(compress.New(compressConfig()))
 	app.Get("/swagger/*", swagger.HandlerDefault)
+	healthHandler := handlers.NewHealthHandler(database.DB)
+	app.Get("/health", healthHandler.Health)
+	app.Get("/ready", healthHandler.Ready)
+	app.Get("/metrics", handlers.NewMetricsHandler().Metrics)
 	globalPrefix := app.Group("/api")
+	statusHandler := handlers.NewStatusHandler(startedAt)
+	globalPrefix.Get("/status", middlewares.RequireAuth(database.DB), middlewares.RequireAdmin(database.DB), statusHandler.Status)
 	userRoutes := globalPrefix.Group("/users")
 	routes.SetupUserRoutes(userRoutes)
-	log.Fatal(app.Listen(":" + *port))
+	authRoutes := globalPrefix.Group("/auth")
+	routes.SetupAuthRoutes(authRoutes)
+	statsRoutes := globalPrefix.Group("/stats")
+	routes.SetupStatsRoutes(statsRoutes)
+
+	return app
+}
+
+// shutdown stops the server, waiting up to shutdownTimeout for in-flight
+// requests, and then closes the database connection pool.
+func shutdown(app *fiber.App, db *gorm.DB, sig os.Signal) error {
+	log.Printf("Received %s, shutting down", sig)
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		return fmt.Errorf("shutting down server: %w", err)
+	}
+	log.Println("Server stopped")
+	if db == nil {
+		return nil
+	}
+	connection, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("getting database connection: %w", err)
+	}
+	if err := connection.Close(); err != nil {
+		return fmt.Errorf("closing database connection: %w", err)
+	}
+	log.Println("Database connection closed")
+	return nil
+}
+
+// corsConfig builds the CORS settings from CORS_ORIGINS, a comma-separated
+// list of allowed origins. Every origin is allowed when it is unset or lists
+// "*", and credentials are only allowed for an explicit list of origins.
+// Entries that are not valid origins are logged and skipped rather than
+// left for cors.New to panic on.
+func corsConfig() cors.Config {
+	value := os.Getenv("CORS_ORIGINS")
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimSpace(origin)
+		switch {
+		case origin == "":
+		case origin == "*":
+			if strings.TrimSpace(value) != "*" {
+				log.Printf("CORS_ORIGINS %q contains *, allowing every origin without credentials", value)
+			}
+			return cors.Config{AllowOrigins: "*"}
+		case !validOrigin(origin):
+			log.Printf("Ignoring invalid CORS origin %q", origin)
+		default:
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		if strings.TrimSpace(value) == "" {
+			return cors.Config{AllowOrigins: "*"}
+		}
+		// Failing open would undo the restriction the operator asked for.
+		log.Printf("CORS_ORIGINS %q has no valid origins, rejecting all cross-origin requests", value)
+		return cors.Config{AllowOriginsFunc: func(string) bool { return false }}
+	}
+	return cors.Config{
+		AllowOrigins:     strings.Join(origins, ","),
+		AllowCredentials: true,
+	}
+}
+
+// validOrigin reports whether origin is an http(s) scheme and host, as
+// cors.New requires, optionally with a leading "*." subdomain wildcard.
+func validOrigin(origin string) bool {
+	origin = strings.Replace(origin, "://*.", "://", 1)
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") &&
+		parsed.Host != "" && !strings.Contains(parsed.Host, "*") &&
+		(parsed.Path == "" || parsed.Path == "/") && parsed.RawQuery == "" && parsed.Fragment == ""
+}
+
+// compressConfig builds the compression settings from COMPRESS_LEVEL, one of
+// -1 (disabled), 0 (default), 1 (best speed) or 2 (best compression).
+// Unset or invalid values fall back to the default level.
+func compressConfig() compress.Config {
+	value := os.Getenv("COMPRESS_LEVEL")
+	if value == "" {
+		return compress.Config{Level: compress.LevelDefault}
+	}
+	level, err := strconv.Atoi(value)
+	if err != nil || level < int(compress.LevelDisabled) || level > int(compress.LevelBestCompression) {
+		log.Printf("Ignoring invalid COMPRESS_LEVEL %q", value)
+		return compress.Config{Level: compress.LevelDefault}
+	}
+	return compress.Config{Level: compress.Level(level)}
+}
+
+// bodyLimit reads the maximum request body size in bytes from MAX_BODY_SIZE,
+// defaulting to 1MB.
+func bodyLimit() int {
+	value := os.Getenv("MAX_BODY_SIZE")
+	if value == "" {
+		return defaultBodySize
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		log.Printf("Ignoring invalid MAX_BODY_SIZE %q", value)
+		return defaultBodySize
+	}
+	return size
 }
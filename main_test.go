@@ -0,0 +1,161 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"felix1234567890/go-trello/database"
+	"felix1234567890/go-trello/models"
+	"felix1234567890/go-trello/testutil"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Run(m)
+}
+
+// newTestApp connects database.DB to a private in-memory SQLite database and
+// builds the full server on top of it.
+func newTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+	testutil.UseDB(t)
+	return newApp(time.Now())
+}
+
+// serve starts app on a random local port and returns its base URL. Unlike
+// app.Test, this goes through the real HTTP server, which enforces limits
+// such as BodyLimit.
+func serve(t *testing.T, app *fiber.App) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(listener)
+	t.Cleanup(func() { app.Shutdown() })
+	return "http://" + listener.Addr().String()
+}
+
+func TestBodyLimit(t *testing.T) {
+	t.Setenv("MAX_BODY_SIZE", "128")
+	baseURL := serve(t, newTestApp(t))
+
+	resp, err := http.Post(baseURL+"/api/users/", fiber.MIMEApplicationJSON, strings.NewReader(`{"username":"alice","email":"alice@example.com","password":"password1"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	oversized := `{"username":"alice","email":"alice@example.com","password":"password1","bio":"` + strings.Repeat("x", 256) + `"}`
+	resp, err = http.Post(baseURL+"/api/users/", fiber.MIMEApplicationJSON, strings.NewReader(oversized))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestBodyLimitFromEnv(t *testing.T) {
+	for value, want := range map[string]int{"": defaultBodySize, "2048": 2048, "0": defaultBodySize, "lots": defaultBodySize} {
+		t.Setenv("MAX_BODY_SIZE", value)
+		assert.Equal(t, want, bodyLimit(), "MAX_BODY_SIZE=%q", value)
+	}
+}
+
+func TestCorsConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		env         string
+		origins     string
+		credentials bool
+	}{
+		{"unset", "", "*", false},
+		{"wildcard", "*", "*", false},
+		{"explicit list", "https://a.com, http://localhost:5173", "https://a.com,http://localhost:5173", true},
+		{"wildcard mixed into a list", "https://a.com,*", "*", false},
+		{"missing scheme is skipped", "example.com,https://a.com", "https://a.com", true},
+		{"subdomain wildcard", "https://*.example.com", "https://*.example.com", true},
+		{"path is skipped", "https://a.com/app,https://b.com", "https://b.com", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CORS_ORIGINS", tc.env)
+			config := corsConfig()
+			assert.Equal(t, tc.origins, config.AllowOrigins)
+			assert.Equal(t, tc.credentials, config.AllowCredentials)
+			assert.NotPanics(t, func() { cors.New(config) })
+		})
+	}
+}
+
+func TestCorsConfigWithNoValidOrigins(t *testing.T) {
+	t.Setenv("CORS_ORIGINS", "example.com")
+	config := corsConfig()
+	require.NotPanics(t, func() { cors.New(config) })
+
+	app := fiber.New()
+	app.Use(cors.New(config))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderOrigin, "http://example.com")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+}
+
+func TestShutdownStopsServerAndClosesDB(t *testing.T) {
+	app := newTestApp(t)
+	baseURL := serve(t, app)
+	resp, err := http.Get(baseURL + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.NoError(t, shutdown(app, database.DB, syscall.SIGTERM))
+
+	connection, err := database.DB.DB()
+	require.NoError(t, err)
+	assert.Error(t, connection.Ping(), "database connection should be closed")
+	_, err = http.Get(baseURL + "/health")
+	assert.Error(t, err, "server should no longer accept connections")
+}
+
+func TestListResponsesAreCompressed(t *testing.T) {
+	app := newTestApp(t)
+	for i := 0; i < 20; i++ {
+		user := models.User{Username: fmt.Sprintf("user%02d", i), Email: fmt.Sprintf("user%02d@example.com", i)}
+		require.NoError(t, database.DB.Create(&user).Error)
+	}
+
+	req := httptest.NewRequest(fiber.MethodGet, "/api/users/", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "gzip", resp.Header.Get(fiber.HeaderContentEncoding))
+	reader, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(reader).Decode(&body))
+	assert.Len(t, body["users"], 20)
+}
+
+func TestCompressConfig(t *testing.T) {
+	for value, want := range map[string]compress.Level{
+		"":      compress.LevelDefault,
+		"-1":    compress.LevelDisabled,
+		"2":     compress.LevelBestCompression,
+		"9":     compress.LevelDefault,
+		"gzip!": compress.LevelDefault,
+	} {
+		t.Setenv("COMPRESS_LEVEL", value)
+		assert.Equal(t, want, compressConfig().Level, "COMPRESS_LEVEL=%q", value)
+	}
+}
@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"felix1234567890/go-trello/models"
+	"felix1234567890/go-trello/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RequireAdmin rejects requests whose authenticated user is not an admin.
+// It must run after DeserializeUser or RequireAuth, which identify the user.
+func RequireAdmin(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return unauthorized(c, errNotLoggedIn)
+		}
+		return requireAdmin(c, db, userID)
+	}
+}
+
+// RequireAdminIf authenticates the caller and requires them to be an admin,
+// but only for requests where cond returns true. Other requests pass through
+// untouched, so a public route can still guard an admin-only option.
+func RequireAdminIf(db *gorm.DB, cond func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !cond(c) {
+			return c.Next()
+		}
+		claims, err := parseBearerToken(c)
+		if err != nil {
+			return unauthorized(c, err)
+		}
+
+		c.Locals("user_id", claims.UserID)
+		return requireAdmin(c, db, claims.UserID)
+	}
+}
+
+// RequireSelfOrAdmin lets a request through when the authenticated user is
+// the one named by the :id route parameter, or is an admin. It must run
+// after DeserializeUser or RequireAuth.
+func RequireSelfOrAdmin(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return unauthorized(c, errNotLoggedIn)
+		}
+		if targetID, err := strconv.ParseUint(c.Params("id"), 10, 64); err == nil && uint(targetID) == userID {
+			return c.Next()
+		}
+		return requireAdmin(c, db, userID)
+	}
+}
+
+// IdentifyAdmin sets c.Locals("is_admin") when the request carries a valid
+// token belonging to an admin, so public routes can show admins more. It
+// never rejects a request; anonymous and non-admin callers pass through.
+func IdentifyAdmin(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := parseBearerToken(c)
+		if err != nil {
+			return c.Next()
+		}
+		isAdmin, err := userIsAdmin(c, db, claims.UserID)
+		if err != nil {
+			return utils.HandleInternalError(c, err)
+		}
+		if isAdmin {
+			c.Locals("user_id", claims.UserID)
+			c.Locals("is_admin", true)
+		}
+		return c.Next()
+	}
+}
+
+// requireAdmin continues the chain, with c.Locals("is_admin") set, if userID
+// belongs to an admin and writes a 403 otherwise.
+func requireAdmin(c *fiber.Ctx, db *gorm.DB, userID uint) error {
+	isAdmin, err := userIsAdmin(c, db, userID)
+	if err != nil {
+		return utils.HandleInternalError(c, err)
+	}
+	if !isAdmin {
+		return utils.HandleErrorResponseWithCode(c, fiber.StatusForbidden, utils.ErrCodeForbidden, "You do not have permission to perform this action")
+	}
+
+	c.Locals("is_admin", true)
+	return c.Next()
+}
+
+func userIsAdmin(c *fiber.Ctx, db *gorm.DB, userID uint) (bool, error) {
+	var count int64
+	err := db.WithContext(c.UserContext()).Model(&models.User{}).Where("id = ? AND is_admin = ?", userID, true).Count(&count).Error
+	return count > 0, err
+}
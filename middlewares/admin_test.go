@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"felix1234567890/go-trello/testutil"
+	"felix1234567890/go-trello/utils"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	db := testutil.UseDB(t)
+	_, adminToken := testutil.CreateUser(t, db, "admin", true)
+	_, userToken := testutil.CreateUser(t, db, "alice", false)
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app := fiber.New()
+	app.Get("/auth", RequireAuth(db), RequireAdmin(db), ok)
+	app.Get("/deserialize", DeserializeUser, RequireAdmin(db), ok)
+	app.Get("/unauthenticated", RequireAdmin(db), ok)
+
+	for _, path := range []string{"/auth", "/deserialize"} {
+		t.Run(path, func(t *testing.T) {
+			status, _ := get(t, app, path, adminToken)
+			assert.Equal(t, fiber.StatusOK, status)
+
+			status, code := get(t, app, path, userToken)
+			assert.Equal(t, fiber.StatusForbidden, status)
+			assert.Equal(t, utils.ErrCodeForbidden, code)
+		})
+	}
+	t.Run("without an auth middleware", func(t *testing.T) {
+		status, code := get(t, app, "/unauthenticated", adminToken)
+		assert.Equal(t, fiber.StatusUnauthorized, status)
+		assert.Equal(t, utils.ErrCodeUnauthorized, code)
+	})
+}
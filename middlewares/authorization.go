@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"errors"
 	"felix1234567890/go-trello/database"
 	"felix1234567890/go-trello/models"
 	"felix1234567890/go-trello/utils"
@@ -8,45 +9,102 @@ import (
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
+var (
+	errNotLoggedIn  = errors.New("You are not logged in")
+	errTokenExpired = errors.New("token has expired")
+)
+
+// DeserializeUser validates the bearer token and loads the full user record
+// into c.Locals("user"). Use it when the handler needs the user itself, as
+// GetMe does. Routes that only need to know who is calling should use the
+// cheaper RequireAuth instead.
 func DeserializeUser(c *fiber.Ctx) error {
-	var tokenString string
-	authorization := c.Get("Authorization")
+	claims, err := parseBearerToken(c)
+	if err != nil {
+		return unauthorized(c, err)
+	}
 
-	if strings.HasPrefix(authorization, "Bearer ") {
-		tokenString = strings.TrimPrefix(authorization, "Bearer ")
+	var user models.User
+	if err := database.DB.WithContext(c.UserContext()).First(&user, claims.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.HandleErrorResponseWithCode(c, fiber.StatusForbidden, utils.ErrCodeForbidden, "the user belonging to this token no longer exists")
+		}
+		return utils.HandleInternalError(c, err)
 	}
-	if tokenString == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "fail", "message": "You are not logged in"})
+
+	c.Locals("user", user)
+	if claims.ExpiresAt != nil {
+		c.Locals("token_expires_at", claims.ExpiresAt.Time)
 	}
 
-	tokenByte, err := jwt.Parse(tokenString, func(jwtToken *jwt.Token) (interface{}, error) {
-		if _, ok := jwtToken.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %s", jwtToken.Header["alg"])
+	return c.Next()
+}
+
+// RequireAuth validates the bearer token and stores the caller's ID in
+// c.Locals("user_id"). It only checks that the user still exists instead of
+// loading the record, so handlers that need more than the ID should use
+// DeserializeUser.
+func RequireAuth(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := parseBearerToken(c)
+		if err != nil {
+			return unauthorized(c, err)
 		}
 
-		return []byte(utils.SECRET_KEY), nil
-	})
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "fail", "message": fmt.Sprintf("invalidate token: %v", err)})
-	}
+		var count int64
+		if err := db.WithContext(c.UserContext()).Model(&models.User{}).Where("id = ?", claims.UserID).Count(&count).Error; err != nil {
+			return utils.HandleInternalError(c, err)
+		}
+		if count == 0 {
+			return utils.HandleErrorResponseWithCode(c, fiber.StatusForbidden, utils.ErrCodeForbidden, "the user belonging to this token no longer exists")
+		}
 
-	claims, ok := tokenByte.Claims.(jwt.MapClaims)
-	if !ok || !tokenByte.Valid {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "fail", "message": "invalid token claim"})
+		c.Locals("user_id", claims.UserID)
+		return c.Next()
+	}
+}
 
+// currentUserID returns the authenticated user's ID set by either
+// DeserializeUser or RequireAuth.
+func currentUserID(c *fiber.Ctx) (uint, bool) {
+	if user, ok := c.Locals("user").(models.User); ok {
+		return user.ID, true
 	}
+	id, ok := c.Locals("user_id").(uint)
+	return id, ok
+}
 
-	var user models.User
-	fmt.Println(user.ID)
-	database.DB.First(&user, fmt.Sprint(claims["id"]))
-	if user.ID != uint(claims["id"].(float64)) {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "fail", "message": "the user belonging to this token no logger exists"})
+// unauthorized writes a 401 for a failed token check. Expired tokens get
+// their own code so clients know to refresh rather than log in again.
+func unauthorized(c *fiber.Ctx, err error) error {
+	code := utils.ErrCodeUnauthorized
+	if errors.Is(err, errTokenExpired) {
+		code = utils.ErrCodeTokenExpired
 	}
+	return utils.HandleErrorResponseWithCode(c, fiber.StatusUnauthorized, code, err.Error())
+}
 
-	c.Locals("user", user)
+func parseBearerToken(c *fiber.Ctx) (*utils.AppClaims, error) {
+	var tokenString string
+	authorization := c.Get("Authorization")
 
-	return c.Next()
+	if strings.HasPrefix(authorization, "Bearer ") {
+		tokenString = strings.TrimPrefix(authorization, "Bearer ")
+	}
+	if tokenString == "" {
+		return nil, errNotLoggedIn
+	}
+
+	claims, err := utils.ParseToken(tokenString)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errTokenExpired
+		}
+		return nil, fmt.Errorf("invalidate token: %v", err)
+	}
+	return claims, nil
 }
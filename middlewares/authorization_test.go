@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"felix1234567890/go-trello/testutil"
+	"felix1234567890/go-trello/utils"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newRequireAuthApp(t *testing.T) (*fiber.App, *gorm.DB) {
+	t.Helper()
+	db := testutil.UseDB(t)
+	app := fiber.New()
+	app.Get("/", RequireAuth(db), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"user_id": c.Locals("user_id")})
+	})
+	return app, db
+}
+
+func TestRequireAuth(t *testing.T) {
+	app, db := newRequireAuthApp(t)
+	user, token := testutil.CreateUser(t, db, "alice", false)
+
+	t.Run("valid token", func(t *testing.T) {
+		status, _ := get(t, app, "/", token)
+		assert.Equal(t, fiber.StatusOK, status)
+	})
+	t.Run("missing token", func(t *testing.T) {
+		status, code := get(t, app, "/", "")
+		assert.Equal(t, fiber.StatusUnauthorized, status)
+		assert.Equal(t, utils.ErrCodeUnauthorized, code)
+	})
+	t.Run("expired token", func(t *testing.T) {
+		status, code := get(t, app, "/", testutil.ExpiredToken(t, user.ID))
+		assert.Equal(t, fiber.StatusUnauthorized, status)
+		assert.Equal(t, utils.ErrCodeTokenExpired, code)
+	})
+	t.Run("deleted user", func(t *testing.T) {
+		require.NoError(t, db.Delete(&user).Error)
+		status, code := get(t, app, "/", token)
+		assert.Equal(t, fiber.StatusForbidden, status)
+		assert.Equal(t, utils.ErrCodeForbidden, code)
+	})
+}
+
+func TestDeserializeUserTokenErrors(t *testing.T) {
+	db := testutil.UseDB(t)
+	app := fiber.New()
+	app.Get("/", DeserializeUser, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	user, token := testutil.CreateUser(t, db, "alice", false)
+
+	status, _ := get(t, app, "/", token)
+	assert.Equal(t, fiber.StatusOK, status)
+
+	status, code := get(t, app, "/", testutil.ExpiredToken(t, user.ID))
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Equal(t, utils.ErrCodeTokenExpired, code)
+
+	status, code = get(t, app, "/", "not-a-token")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Equal(t, utils.ErrCodeUnauthorized, code)
+}
+
+func TestDeserializeUserDatabaseError(t *testing.T) {
+	db := testutil.UseDB(t)
+	app := fiber.New()
+	app.Get("/", DeserializeUser, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	_, token := testutil.CreateUser(t, db, "alice", false)
+	connection, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, connection.Close())
+
+	status, code := get(t, app, "/", token)
+	assert.Equal(t, fiber.StatusInternalServerError, status)
+	assert.Equal(t, utils.ErrCodeInternal, code)
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"felix1234567890/go-trello/testutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Run(m)
+}
+
+// get sends a GET request to path with an optional bearer token and returns
+// the status code and the error code of the response, if any.
+func get(t *testing.T, app *fiber.App, path, token string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if resp.Header.Get(fiber.HeaderContentType) == fiber.MIMEApplicationJSON {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	}
+	return resp.StatusCode, body.Error.Code
+}
@@ -0,0 +1,132 @@
+package middlewares
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the latency histogram.
+// They match the Prometheus client defaults so an exporter can reuse them.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RouteMetrics is a snapshot of the requests served by one route.
+type RouteMetrics struct {
+	Method      string         `json:"method"`
+	Path        string         `json:"path"`
+	Count       uint64         `json:"count"`
+	StatusCodes map[int]uint64 `json:"status_codes"`
+	Latency     Histogram      `json:"latency_seconds"`
+}
+
+// Histogram is a cumulative latency histogram in the Prometheus layout: each
+// bucket counts the requests that took at most Le seconds.
+type Histogram struct {
+	Buckets []HistogramBucket `json:"buckets"`
+	Sum     float64           `json:"sum"`
+	Count   uint64            `json:"count"`
+}
+
+// HistogramBucket is one cumulative bucket of a Histogram.
+type HistogramBucket struct {
+	Le    float64 `json:"le"`
+	Count uint64  `json:"count"`
+}
+
+type routeKey struct {
+	method string
+	path   string
+}
+
+type routeCounters struct {
+	count       uint64
+	statusCodes map[int]uint64
+	// buckets[i] counts requests that fell in bucket i alone; the last
+	// entry holds those slower than every bound.
+	buckets []uint64
+	sum     float64
+}
+
+var metrics = struct {
+	sync.Mutex
+	routes map[routeKey]*routeCounters
+}{routes: make(map[routeKey]*routeCounters)}
+
+// Metrics records the request count, status codes and latency of every
+// route into in-process counters, which MetricsSnapshot reads. Requests are
+// grouped by route pattern, such as /api/users/:id, rather than raw path, so
+// requests that match no route all land under the pattern the middleware
+// itself is mounted on.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+		recordRequest(routeKey{method: c.Method(), path: c.Route().Path}, status, time.Since(start))
+		return err
+	}
+}
+
+func recordRequest(key routeKey, status int, latency time.Duration) {
+	seconds := latency.Seconds()
+	bucket := sort.SearchFloat64s(latencyBuckets, seconds)
+
+	metrics.Lock()
+	defer metrics.Unlock()
+	counters, ok := metrics.routes[key]
+	if !ok {
+		counters = &routeCounters{
+			statusCodes: make(map[int]uint64),
+			buckets:     make([]uint64, len(latencyBuckets)+1),
+		}
+		metrics.routes[key] = counters
+	}
+	counters.count++
+	counters.statusCodes[status]++
+	counters.buckets[bucket]++
+	counters.sum += seconds
+}
+
+// MetricsSnapshot returns the counters recorded by Metrics so far, sorted by
+// path and method.
+func MetricsSnapshot() []RouteMetrics {
+	metrics.Lock()
+	defer metrics.Unlock()
+	snapshot := make([]RouteMetrics, 0, len(metrics.routes))
+	for key, counters := range metrics.routes {
+		statusCodes := make(map[int]uint64, len(counters.statusCodes))
+		for status, count := range counters.statusCodes {
+			statusCodes[status] = count
+		}
+		buckets := make([]HistogramBucket, len(latencyBuckets))
+		var cumulative uint64
+		for i, le := range latencyBuckets {
+			cumulative += counters.buckets[i]
+			buckets[i] = HistogramBucket{Le: le, Count: cumulative}
+		}
+		snapshot = append(snapshot, RouteMetrics{
+			Method:      key.method,
+			Path:        key.path,
+			Count:       counters.count,
+			StatusCodes: statusCodes,
+			Latency:     Histogram{Buckets: buckets, Sum: counters.sum, Count: counters.count},
+		})
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Path != snapshot[j].Path {
+			return snapshot[i].Path < snapshot[j].Path
+		}
+		return snapshot[i].Method < snapshot[j].Method
+	})
+	return snapshot
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routeMetrics returns the snapshot entry for method and path, if any.
+func routeMetrics(method, path string) (RouteMetrics, bool) {
+	for _, route := range MetricsSnapshot() {
+		if route.Method == method && route.Path == path {
+			return route, true
+		}
+	}
+	return RouteMetrics{}, false
+}
+
+func TestMetricsCountsRequests(t *testing.T) {
+	app := fiber.New()
+	app.Use(Metrics())
+	app.Get("/metrics-test/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "teapot" {
+			return fiber.ErrTeapot
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+	// The counters are package-level, so compare against what earlier runs
+	// left behind.
+	before, _ := routeMetrics(fiber.MethodGet, "/metrics-test/:id")
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics-test/teapot"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	route, ok := routeMetrics(fiber.MethodGet, "/metrics-test/:id")
+	require.True(t, ok, "requests should be grouped under the route pattern")
+	assert.Equal(t, before.Count+3, route.Count)
+	assert.Equal(t, before.StatusCodes[fiber.StatusOK]+2, route.StatusCodes[fiber.StatusOK])
+	assert.Equal(t, before.StatusCodes[fiber.StatusTeapot]+1, route.StatusCodes[fiber.StatusTeapot])
+	assert.Equal(t, before.Latency.Count+3, route.Latency.Count)
+	require.Len(t, route.Latency.Buckets, len(latencyBuckets))
+	// Buckets are cumulative, so the last one holds every request that
+	// finished within 10s.
+	assert.Equal(t, route.Count, route.Latency.Buckets[len(latencyBuckets)-1].Count)
+	for i := 1; i < len(route.Latency.Buckets); i++ {
+		assert.GreaterOrEqual(t, route.Latency.Buckets[i].Count, route.Latency.Buckets[i-1].Count)
+	}
+}
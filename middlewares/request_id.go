@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader carries the request ID on both requests and responses.
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID tags every request with an ID so its log lines and error
+// responses can be correlated. An incoming X-Request-ID header is reused,
+// otherwise a new UUID is generated. The ID is stored in
+// c.Locals("request_id") and echoed in the X-Request-ID response header.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		c.Locals("request_id", requestID)
+		c.Set(RequestIDHeader, requestID)
+		return c.Next()
+	}
+}
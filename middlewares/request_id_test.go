@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"felix1234567890/go-trello/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestIDApp returns an app whose only route fails, so its response
+// carries the request ID in both the header and the error body.
+func requestIDApp() *fiber.App {
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return utils.HandleErrorResponse(c, fiber.StatusNotFound, "not here")
+	})
+	return app
+}
+
+// bodyRequestID returns the request_id field of an error response.
+func bodyRequestID(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	var body struct {
+		Error struct {
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body.Error.RequestID
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	resp, err := requestIDApp().Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	requestID := resp.Header.Get(RequestIDHeader)
+	_, err = uuid.Parse(requestID)
+	assert.NoError(t, err, "X-Request-ID should be a UUID, got %q", requestID)
+	assert.Equal(t, requestID, bodyRequestID(t, resp))
+}
+
+func TestRequestIDEchoesIncoming(t *testing.T) {
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "trace-123")
+	resp, err := requestIDApp().Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "trace-123", resp.Header.Get(RequestIDHeader))
+	assert.Equal(t, "trace-123", bodyRequestID(t, resp))
+}
+
+func TestRequestIDReplacesOversizedIncoming(t *testing.T) {
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, strings.Repeat("a", maxRequestIDLength+1))
+	resp, err := requestIDApp().Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	_, err = uuid.Parse(resp.Header.Get(RequestIDHeader))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestBaseJSONKeys(t *testing.T) {
+	now := time.Now()
+	user := User{Base: Base{ID: 1, CreatedAt: now, UpdatedAt: now, DeletedAt: gorm.DeletedAt{Time: now, Valid: true}}}
+
+	keys := jsonKeys(t, user)
+	assert.Contains(t, keys, "createdAt")
+	assert.Contains(t, keys, "updatedAt")
+	assert.NotContains(t, keys, "CreatedAt")
+	assert.NotContains(t, keys, "UpdatedAt")
+	assert.NotContains(t, keys, "deletedAt")
+	assert.NotContains(t, keys, "DeletedAt")
+}
@@ -0,0 +1,17 @@
+package models
+
+// DailyCount is the number of rows created on a single calendar day.
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+// PeriodCounts holds the number of rows created during a period.
+type PeriodCounts struct {
+	NewUsers int64 `json:"new_users"`
+}
+
+// Totals holds the current number of live rows.
+type Totals struct {
+	Users int64 `json:"users"`
+}
@@ -1,24 +1,49 @@
 package models
 
-import "gorm.io/gorm"
+import "time"
 
 type User struct {
-	gorm.Model
-	Username string `json:"username"`
-	Email    string `json:"email" gorm:"unique"`
-	Password string `json:"password"`
+	Base
+	Username        string     `json:"username"`
+	Email           string     `json:"email" gorm:"unique"`
+	Password        string     `json:"password"`
+	Bio             string     `json:"bio"`
+	AvatarURL       string     `json:"avatarUrl"`
+	IsAdmin         bool       `json:"isAdmin" gorm:"default:false"`
+	IsEmailVerified bool       `json:"isEmailVerified" gorm:"default:false"`
+	LastLoginAt     *time.Time `json:"lastLoginAt"`
 }
 
+type UserResponse struct {
+	ID              uint      `json:"id"`
+	Username        string    `json:"username"`
+	Email           string    `json:"email"`
+	Bio             string    `json:"bio"`
+	AvatarURL       string    `json:"avatarUrl"`
+	IsAdmin         bool      `json:"isAdmin"`
+	IsEmailVerified bool      `json:"isEmailVerified"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	// LastLoginAt is only set for the user themselves and admins; see ToPrivateResponse.
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+	// DeletedAt is only set for soft-deleted users, which only admins can fetch.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// Passwords are capped at 72 bytes because bcrypt ignores anything past
+// that, and must satisfy utils' strongpassword rule.
 type CreateUserRequest struct {
-	Username string `json:"username" validate:"required,min=5"`
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Username string `json:"username" validate:"required,min=5,max=50"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,maxbytes=72,strongpassword"`
 }
 
 type UpdateUserRequest struct {
-	Username string `json:"username" validate:"omitempty,min=5"`
-	Email    string `json:"email" validate:"omitempty,email"`
-	Password string `json:"password" validate:"omitempty,min=6"`
+	Username  string `json:"username" validate:"omitempty,min=5,max=50"`
+	Email     string `json:"email" validate:"omitempty,email,max=254"`
+	Password  string `json:"password" validate:"omitempty,maxbytes=72,strongpassword"`
+	Bio       string `json:"bio" validate:"omitempty,max=500"`
+	AvatarURL string `json:"avatarUrl" validate:"omitempty,url"`
 }
 
 type LoginUserRequest struct {
@@ -29,3 +54,47 @@ type LoginUserRequest struct {
 func (createDto *CreateUserRequest) ToUser() *User {
 	return &User{Username: createDto.Username, Email: createDto.Email, Password: createDto.Password}
 }
+
+func (user *User) ToResponse() UserResponse {
+	response := UserResponse{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		Bio:             user.Bio,
+		AvatarURL:       user.AvatarURL,
+		IsAdmin:         user.IsAdmin,
+		IsEmailVerified: user.IsEmailVerified,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+	if user.DeletedAt.Valid {
+		response.DeletedAt = &user.DeletedAt.Time
+	}
+	return response
+}
+
+// ToPrivateResponse is ToResponse plus the fields only the user themselves
+// and admins should see, such as the last login time.
+func (user *User) ToPrivateResponse() UserResponse {
+	response := user.ToResponse()
+	response.LastLoginAt = user.LastLoginAt
+	return response
+}
+
+// ToPrivateUserResponses is ToUserResponses using ToPrivateResponse, for
+// admin views.
+func ToPrivateUserResponses(users []User) []UserResponse {
+	responses := make([]UserResponse, len(users))
+	for i := range users {
+		responses[i] = users[i].ToPrivateResponse()
+	}
+	return responses
+}
+
+func ToUserResponses(users []User) []UserResponse {
+	responses := make([]UserResponse, len(users))
+	for i := range users {
+		responses[i] = users[i].ToResponse()
+	}
+	return responses
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func jsonKeys(t *testing.T, v interface{}) []string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &fields))
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestUserResponseFieldNames(t *testing.T) {
+	user := User{Username: "alice", Email: "alice@example.com", Password: "hashed"}
+
+	assert.Equal(t, []string{
+		"avatarUrl", "bio", "createdAt", "email", "id", "isAdmin", "isEmailVerified", "updatedAt", "username",
+	}, jsonKeys(t, user.ToResponse()))
+}
+
+func TestUserResponsesNeverIncludePassword(t *testing.T) {
+	now := time.Now()
+	user := User{Username: "alice", Password: "hashed", LastLoginAt: &now}
+
+	for _, response := range []UserResponse{user.ToResponse(), user.ToPrivateResponse(), ToUserResponses([]User{user})[0]} {
+		assert.NotContains(t, jsonKeys(t, response), "password")
+	}
+	assert.Contains(t, jsonKeys(t, user.ToPrivateResponse()), "lastLoginAt")
+	assert.NotContains(t, jsonKeys(t, user.ToResponse()), "lastLoginAt")
+}
@@ -1,8 +1,12 @@
 package repository
 
 import (
+	"context"
+	"errors"
 	"felix1234567890/go-trello/models"
 	"felix1234567890/go-trello/utils"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -16,24 +20,58 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 		DB: db,
 	}
 }
-func (r *UserRepository) GetUsers() ([]models.User, error) {
+func (r *UserRepository) GetUsers(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	if err := r.DB.Find(&users).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (r *UserRepository) GetUserById(id string) (models.User, error) {
+// SearchUsers returns one page of users whose username, email or bio
+// contains q, ignoring case, along with the total number of matches.
+func (r *UserRepository) SearchUsers(ctx context.Context, q string, page, pageSize int) ([]models.User, int64, error) {
+	pattern := "%" + escapeLike(strings.ToLower(q)) + "%"
+	query := r.DB.WithContext(ctx).Model(&models.User{}).
+		Where("LOWER(username) LIKE ? ESCAPE '!' OR LOWER(email) LIKE ? ESCAPE '!' OR LOWER(bio) LIKE ? ESCAPE '!'", pattern, pattern, pattern)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []models.User
+	if err := query.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// GetUserById finds a user by ID. Soft-deleted users are only returned when
+// includeDeleted is set.
+func (r *UserRepository) GetUserById(ctx context.Context, id string, includeDeleted bool) (models.User, error) {
+	var user models.User
+	query := r.DB.WithContext(ctx)
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	if err := query.First(&user, id).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// GetUserByEmail looks a user up by email, normalized the same way as on
+// login. It returns gorm.ErrRecordNotFound when there is no such user.
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
 	var user models.User
-	if err := r.DB.First(&user, id).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Where("email = ?", utils.NormalizeEmail(email)).First(&user).Error; err != nil {
 		return models.User{}, err
 	}
 	return user, nil
 }
 
-func (r *UserRepository) DeleteUser(id string) error {
-	result := r.DB.Delete(&models.User{}, id)
+func (r *UserRepository) DeleteUser(ctx context.Context, id string) error {
+	result := r.DB.WithContext(ctx).Delete(&models.User{}, id)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -43,9 +81,20 @@ func (r *UserRepository) DeleteUser(id string) error {
 	return nil
 }
 
-func (r *UserRepository) UpdateUser(id string, req *models.UpdateUserRequest) error {
-	result := r.DB.Model(&models.User{}).Where("id = ?", id).Updates(&req)
+func (r *UserRepository) UpdateUser(ctx context.Context, id string, req *models.UpdateUserRequest) error {
+	req.Email = utils.NormalizeEmail(req.Email)
+	if req.Password != "" {
+		hashedPassword, err := utils.HashPassword(req.Password)
+		if err != nil {
+			return err
+		}
+		req.Password = hashedPassword
+	}
+	result := r.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(&req)
 	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return utils.ErrUserAlreadyExists
+		}
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
@@ -54,28 +103,124 @@ func (r *UserRepository) UpdateUser(id string, req *models.UpdateUserRequest) er
 	return nil
 }
 
-func (r *UserRepository) CreateUser(req *models.User) (uint, error) {
+func (r *UserRepository) CreateUser(ctx context.Context, req *models.User) (uint, error) {
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return 0, err
 	}
 	req.Password = hashedPassword
-	result := r.DB.Create(&req)
+	req.Email = utils.NormalizeEmail(req.Email)
+	result := r.DB.WithContext(ctx).Create(&req)
 	if result.Error != nil {
+		// Concurrent registrations can both get this far; the unique index
+		// on email decides the winner.
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return 0, utils.ErrUserAlreadyExists
+		}
 		return 0, result.Error
 	}
 	return req.ID, nil
 }
 
-func (r *UserRepository) Login(LoginUserRequest *models.LoginUserRequest) (uint, error) {
+// EmailExists reports whether any user, including soft-deleted ones, already
+// has the given email. Soft-deleted rows still hold the unique index entry.
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Unscoped().Model(&models.User{}).
+		Where("email = ?", utils.NormalizeEmail(email)).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
 
+// Login checks the credentials and, on success, records the login time in
+// the same transaction as the lookup. With requireVerifiedEmail set, users
+// who have not confirmed their email get ErrEmailNotVerified instead.
+func (r *UserRepository) Login(ctx context.Context, LoginUserRequest *models.LoginUserRequest, requireVerifiedEmail bool) (uint, error) {
 	var user models.User
-	result := r.DB.Where("email = ?", LoginUserRequest.Email).First(&user)
-	if result.Error != nil {
-		return 0, result.Error
-	}
-	if err := utils.CheckPasswordHash(LoginUserRequest.Password, user.Password); err != nil {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("email = ?", utils.NormalizeEmail(LoginUserRequest.Email)).First(&user)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return utils.ErrInvalidCredentials
+			}
+			return result.Error
+		}
+		if err := utils.CheckPasswordHash(LoginUserRequest.Password, user.Password); err != nil {
+			return utils.ErrInvalidCredentials
+		}
+		if requireVerifiedEmail && !user.IsEmailVerified {
+			return utils.ErrEmailNotVerified
+		}
+		return tx.Model(&user).UpdateColumn("last_login_at", time.Now()).Error
+	})
+	if err != nil {
 		return 0, err
 	}
 	return user.ID, nil
 }
+
+// CountRegistrationsByDay returns the number of users created on each day
+// since the given time, keyed by date in YYYY-MM-DD form. Days without
+// registrations are absent from the map.
+func (r *UserRepository) CountRegistrationsByDay(ctx context.Context, since time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Day   string
+		Count int64
+	}
+	err := r.DB.WithContext(ctx).Model(&models.User{}).
+		Select("DATE(created_at) AS day, COUNT(*) AS count").
+		Where("created_at >= ?", since).
+		Group("DATE(created_at)").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		// MySQL scans DATE columns as full timestamps, SQLite as plain dates;
+		// the leading YYYY-MM-DD is the same in both.
+		if len(row.Day) > len("2006-01-02") {
+			row.Day = row.Day[:len("2006-01-02")]
+		}
+		counts[row.Day] = row.Count
+	}
+	return counts, nil
+}
+
+// VerifyEmail marks the email of user id as verified. Verifying an already
+// verified user is a no-op.
+func (r *UserRepository) VerifyEmail(ctx context.Context, id uint) error {
+	var user models.User
+	if err := r.DB.WithContext(ctx).First(&user, id).Error; err != nil {
+		return err
+	}
+	if user.IsEmailVerified {
+		return nil
+	}
+	return r.DB.WithContext(ctx).Model(&user).UpdateColumn("is_email_verified", true).Error
+}
+
+// CountAll returns the number of users, excluding soft-deleted ones.
+func (r *UserRepository) CountAll(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.User{}).Count(&count).Error
+	return count, err
+}
+
+// CountCreatedBetween returns the number of users created in [from, to).
+func (r *UserRepository) CountCreatedBetween(ctx context.Context, from, to time.Time) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.User{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Count(&count).Error
+	return count, err
+}
+
+// escapeLike escapes LIKE wildcards in s using '!' as the escape character,
+// which both MySQL and SQLite accept in an ESCAPE clause.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"felix1234567890/go-trello/models"
+	"felix1234567890/go-trello/testutil"
+	"felix1234567890/go-trello/utils"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newTestRepo returns a repository backed by a private in-memory SQLite
+// database opened through the same connection code the server uses.
+func newTestRepo(t *testing.T) *UserRepository {
+	t.Helper()
+	return NewUserRepository(testutil.NewDB(t))
+}
+
+func mustCreateUser(t *testing.T, r *UserRepository, username, email, password string) uint {
+	t.Helper()
+	id, err := r.CreateUser(context.Background(), &models.User{Username: username, Email: email, Password: password})
+	require.NoError(t, err)
+	return id
+}
+
+func TestUpdateUserHashesPassword(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	id := mustCreateUser(t, r, "alice", "alice@example.com", "oldpass123")
+
+	err := r.UpdateUser(ctx, fmt.Sprint(id), &models.UpdateUserRequest{Password: "newpass123"})
+	require.NoError(t, err)
+
+	var stored models.User
+	require.NoError(t, r.DB.First(&stored, id).Error)
+	assert.NotEqual(t, "newpass123", stored.Password)
+	loggedIn, err := r.Login(ctx, &models.LoginUserRequest{Email: "alice@example.com", Password: "newpass123"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, id, loggedIn)
+}
+
+func TestUpdateUserRejectsPasswordOverBcryptLimit(t *testing.T) {
+	r := newTestRepo(t)
+	mustCreateUser(t, r, "alice", "alice@example.com", "oldpass123")
+
+	err := r.UpdateUser(context.Background(), "1", &models.UpdateUserRequest{Password: strings.Repeat("a", utils.MaxPasswordBytes+1)})
+	assert.ErrorIs(t, err, utils.ErrPasswordTooLong)
+}
+
+func TestUpdateUserMissingUser(t *testing.T) {
+	r := newTestRepo(t)
+
+	err := r.UpdateUser(context.Background(), "42", &models.UpdateUserRequest{Bio: "hello"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestLoginRecordsLastLoginTime(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	id := mustCreateUser(t, r, "alice", "alice@example.com", "password1")
+	earlier := time.Now().Add(-time.Hour)
+	require.NoError(t, r.DB.Model(&models.User{}).Where("id = ?", id).UpdateColumn("last_login_at", earlier).Error)
+
+	_, err := r.Login(ctx, &models.LoginUserRequest{Email: "alice@example.com", Password: "password1"}, false)
+	require.NoError(t, err)
+
+	var user models.User
+	require.NoError(t, r.DB.First(&user, id).Error)
+	require.NotNil(t, user.LastLoginAt)
+	assert.True(t, user.LastLoginAt.After(earlier))
+}
+
+func TestFailedLoginKeepsLastLoginTime(t *testing.T) {
+	r := newTestRepo(t)
+	id := mustCreateUser(t, r, "alice", "alice@example.com", "password1")
+
+	_, err := r.Login(context.Background(), &models.LoginUserRequest{Email: "alice@example.com", Password: "wrongpass1"}, false)
+	assert.ErrorIs(t, err, utils.ErrInvalidCredentials)
+
+	var user models.User
+	require.NoError(t, r.DB.First(&user, id).Error)
+	assert.Nil(t, user.LastLoginAt)
+}
+
+func TestQueriesStopOnCancelledContext(t *testing.T) {
+	r := newTestRepo(t)
+	mustCreateUser(t, r, "alice", "alice@example.com", "password1")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := r.GetUsers(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = r.GetUserById(ctx, "1", false)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestUpdateUserBioOnly(t *testing.T) {
+	r := newTestRepo(t)
+	id := mustCreateUser(t, r, "alice", "alice@example.com", "password1")
+
+	require.NoError(t, r.UpdateUser(context.Background(), fmt.Sprint(id), &models.UpdateUserRequest{Bio: "Hello there"}))
+
+	user, err := r.GetUserById(context.Background(), fmt.Sprint(id), false)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there", user.Bio)
+	assert.Equal(t, "alice", user.Username)
+	assert.Equal(t, "alice@example.com", user.Email)
+}
+
+func TestLoginIgnoresEmailCase(t *testing.T) {
+	r := newTestRepo(t)
+	id := mustCreateUser(t, r, "alice", "  Alice@Example.COM ", "password1")
+
+	loggedIn, err := r.Login(context.Background(), &models.LoginUserRequest{Email: "aLiCe@example.com", Password: "password1"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, id, loggedIn)
+}
+
+func TestLoginFailuresAreIndistinguishable(t *testing.T) {
+	r := newTestRepo(t)
+	mustCreateUser(t, r, "alice", "alice@example.com", "password1")
+
+	_, err := r.Login(context.Background(), &models.LoginUserRequest{Email: "nobody@example.com", Password: "password1"}, false)
+	assert.ErrorIs(t, err, utils.ErrInvalidCredentials)
+	_, err = r.Login(context.Background(), &models.LoginUserRequest{Email: "alice@example.com", Password: "wrongpass1"}, false)
+	assert.ErrorIs(t, err, utils.ErrInvalidCredentials)
+}
+
+func TestEmailExists(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	id := mustCreateUser(t, r, "alice", "alice@example.com", "password1")
+
+	exists, err := r.EmailExists(ctx, " Alice@Example.COM ")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = r.EmailExists(ctx, "bob@example.com")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// A soft-deleted user still holds the unique index entry.
+	require.NoError(t, r.DeleteUser(ctx, fmt.Sprint(id)))
+	exists, err = r.EmailExists(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	id := mustCreateUser(t, r, "alice", "alice@example.com", "password1")
+
+	user, err := r.GetUserByEmail(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+
+	user, err = r.GetUserByEmail(ctx, "  ALICE@Example.com ")
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+
+	_, err = r.GetUserByEmail(ctx, "bob@example.com")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestSearchUsers(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	aliceID := mustCreateUser(t, r, "AliceSmith", "alice@example.com", "password1")
+	bobID := mustCreateUser(t, r, "bobjones", "bob@corp.test", "password1")
+	carolID := mustCreateUser(t, r, "carolwhite", "carol@example.com", "password1")
+	require.NoError(t, r.DB.Model(&models.User{}).Where("id = ?", carolID).Update("bio", "Loves Gardening").Error)
+
+	tests := []struct {
+		q    string
+		want []uint
+	}{
+		{"alicesmith", []uint{aliceID}},
+		{"CORP.TEST", []uint{bobID}},
+		{"garden", []uint{carolID}},
+		{"example", []uint{aliceID, carolID}},
+		{"nobody", nil},
+		// LIKE wildcards are matched literally.
+		{"%", nil},
+	}
+	for _, tt := range tests {
+		users, total, err := r.SearchUsers(ctx, tt.q, 1, 20)
+		require.NoError(t, err, tt.q)
+		var ids []uint
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+		assert.Equal(t, tt.want, ids, tt.q)
+		assert.Equal(t, int64(len(tt.want)), total, tt.q)
+	}
+}
+
+func TestGetUserByIdAfterSoftDelete(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	id := mustCreateUser(t, r, "alice", "alice@example.com", "password1")
+	require.NoError(t, r.DeleteUser(ctx, fmt.Sprint(id)))
+
+	_, err := r.GetUserById(ctx, fmt.Sprint(id), false)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	user, err := r.GetUserById(ctx, fmt.Sprint(id), true)
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.True(t, user.DeletedAt.Valid)
+}
+
+func TestUpdateUserToTakenEmail(t *testing.T) {
+	r := newTestRepo(t)
+	mustCreateUser(t, r, "alice", "alice@example.com", "password1")
+	id := mustCreateUser(t, r, "bobby", "bob@example.com", "password1")
+
+	err := r.UpdateUser(context.Background(), fmt.Sprint(id), &models.UpdateUserRequest{Email: " Alice@Example.com"})
+	assert.ErrorIs(t, err, utils.ErrUserAlreadyExists)
+}
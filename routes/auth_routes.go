@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"felix1234567890/go-trello/handlers"
+	"felix1234567890/go-trello/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupAuthRoutes(app fiber.Router) {
+	authHandler := handlers.NewAuthHandler()
+
+	app.Get("/validate", middlewares.DeserializeUser, authHandler.Validate)
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"felix1234567890/go-trello/testutil"
+	"felix1234567890/go-trello/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateToken(t *testing.T) {
+	db := testutil.UseDB(t)
+	app := fiber.New()
+	SetupAuthRoutes(app.Group("/api/auth"))
+	user, token := testutil.CreateUser(t, db, "alice", false)
+
+	status, body := doRequest(t, app, http.MethodGet, "/api/auth/validate", "", token)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, body["valid"])
+	assert.Equal(t, float64(user.ID), body["user_id"])
+	expiresAt, err := time.Parse(time.RFC3339, body["expires_at"].(string))
+	require.NoError(t, err)
+	assert.True(t, expiresAt.After(time.Now()))
+
+	status, body = doRequest(t, app, http.MethodGet, "/api/auth/validate", "", testutil.ExpiredToken(t, user.ID))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, utils.ErrCodeTokenExpired, errorCode(body))
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"felix1234567890/go-trello/utils"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorResponseCodes(t *testing.T) {
+	app, _ := newUserApp(t)
+
+	status, body := doRequest(t, app, fiber.MethodGet, "/api/users/999", "", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Equal(t, utils.ErrCodeUserNotFound, errorCode(body))
+
+	status, body = doRequest(t, app, fiber.MethodPost, "/api/users", `{"username":"al","email":"not-an-email","password":"x"}`, "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Equal(t, utils.ErrCodeValidation, errorCode(body))
+	errorBody, _ := body["error"].(map[string]interface{})
+	assert.NotEmpty(t, errorBody["message"])
+}
+
+func TestNullBodyIsAValidationError(t *testing.T) {
+	app, _ := newUserApp(t)
+
+	status, body := doRequest(t, app, fiber.MethodPost, "/api/users", `null`, "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Equal(t, utils.ErrCodeValidation, errorCode(body))
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"encoding/json"
+	"felix1234567890/go-trello/testutil"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Run(m)
+}
+
+// newUserApp returns an app serving the user routes under /api/users.
+func newUserApp(t *testing.T) (*fiber.App, *gorm.DB) {
+	t.Helper()
+	db := testutil.UseDB(t)
+	app := fiber.New()
+	SetupUserRoutes(app.Group("/api/users"))
+	return app, db
+}
+
+// doRequest sends a request to app and decodes the JSON response body.
+func doRequest(t *testing.T, app *fiber.App, method, path, body, token string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	if len(raw) > 0 && resp.Header.Get("Content-Type") == fiber.MIMEApplicationJSON {
+		require.NoError(t, json.Unmarshal(raw, &decoded))
+	}
+	return resp.StatusCode, decoded
+}
+
+// errorCode returns the "code" field of an error response.
+func errorCode(body map[string]interface{}) string {
+	errorBody, _ := body["error"].(map[string]interface{})
+	code, _ := errorBody["code"].(string)
+	return code
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"felix1234567890/go-trello/database"
+	"felix1234567890/go-trello/handlers"
+	"felix1234567890/go-trello/middlewares"
+	"felix1234567890/go-trello/repository"
+	"felix1234567890/go-trello/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupStatsRoutes(app fiber.Router) {
+	userRepository := repository.NewUserRepository(database.DB)
+	statsService := service.NewStatsService(userRepository)
+	statsHandler := handlers.NewStatsHandler(statsService)
+
+	app.Use(middlewares.RequireAuth(database.DB), middlewares.RequireAdmin(database.DB))
+	app.Get("/", statsHandler.GetTotals)
+	app.Get("/registrations", statsHandler.GetRegistrations)
+	app.Get("/this-month", statsHandler.GetThisMonth)
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"felix1234567890/go-trello/testutil"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStatsTotals(t *testing.T) {
+	db := testutil.UseDB(t)
+	app := fiber.New()
+	SetupStatsRoutes(app.Group("/api/stats"))
+
+	_, adminToken := testutil.CreateUser(t, db, "admin", true)
+	_, userToken := testutil.CreateUser(t, db, "alice", false)
+	testutil.CreateUser(t, db, "bobby", false)
+	deleted, _ := testutil.CreateUser(t, db, "carol", false)
+	require.NoError(t, db.Delete(&deleted).Error)
+
+	status, body := doRequest(t, app, fiber.MethodGet, "/api/stats", "", adminToken)
+	assert.Equal(t, fiber.StatusOK, status)
+	// Soft-deleted users are not counted.
+	assert.Equal(t, map[string]interface{}{"users": float64(3)}, body)
+
+	status, _ = doRequest(t, app, fiber.MethodGet, "/api/stats", "", userToken)
+	assert.Equal(t, fiber.StatusForbidden, status)
+}
@@ -15,12 +15,22 @@ func SetupUserRoutes(app fiber.Router) {
 	userService := service.NewUserService(userRepository)
 	userHandler := handlers.NewUserHandler(userService)
 
-	app.Get("/", userHandler.GetUsers)
+	app.Get("/", middlewares.IdentifyAdmin(database.DB), userHandler.GetUsers)
 	app.Get("/me", middlewares.DeserializeUser, userHandler.GetMe)
-	app.Get("/:id", userHandler.GetUserById)
-	app.Delete("/:id", userHandler.DeleteUser)
-	app.Put("/:id", userHandler.UpdateUser)
+	app.Get("/search", middlewares.IdentifyAdmin(database.DB), userHandler.SearchUsers)
+	app.Get("/verify", userHandler.VerifyEmail)
+	app.Get("/:id", middlewares.IdentifyAdmin(database.DB), middlewares.RequireAdminIf(database.DB, includeDeleted), userHandler.GetUserById)
+	app.Delete("/me", middlewares.RequireAuth(database.DB), userHandler.DeleteMe)
+	app.Delete("/:id", middlewares.DeserializeUser, middlewares.RequireSelfOrAdmin(database.DB), userHandler.DeleteUser)
+	app.Put("/me", middlewares.RequireAuth(database.DB), userHandler.UpdateMe)
+	app.Put("/:id", middlewares.DeserializeUser, middlewares.RequireSelfOrAdmin(database.DB), userHandler.UpdateUser)
 	app.Post("/", userHandler.CreateUser)
 	app.Post("/login", userHandler.Login)
 
 }
+
+// includeDeleted reports whether the request asks for soft-deleted users,
+// which only admins may see.
+func includeDeleted(c *fiber.Ctx) bool {
+	return c.QueryBool("includeDeleted")
+}
@@ -0,0 +1,242 @@
+package routes
+
+import (
+	"felix1234567890/go-trello/models"
+	"felix1234567890/go-trello/testutil"
+	"felix1234567890/go-trello/utils"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateMeEditsOwnProfile(t *testing.T) {
+	app, db := newUserApp(t)
+	user, token := testutil.CreateUser(t, db, "alice", false)
+	other, _ := testutil.CreateUser(t, db, "bobby", false)
+
+	status, _ := doRequest(t, app, http.MethodPut, "/api/users/me", fmt.Sprintf(`{"id":%d,"bio":"hello"}`, other.ID), token)
+	assert.Equal(t, http.StatusOK, status)
+
+	var updated, untouched models.User
+	require.NoError(t, db.First(&updated, user.ID).Error)
+	require.NoError(t, db.First(&untouched, other.ID).Error)
+	assert.Equal(t, "hello", updated.Bio)
+	assert.Empty(t, untouched.Bio)
+}
+
+func TestUpdateMeRequiresLogin(t *testing.T) {
+	app, _ := newUserApp(t)
+
+	status, body := doRequest(t, app, http.MethodPut, "/api/users/me", `{"bio":"hello"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, utils.ErrCodeUnauthorized, errorCode(body))
+}
+
+func TestUpdateMeHashesNewPassword(t *testing.T) {
+	app, db := newUserApp(t)
+	user, token := testutil.CreateUser(t, db, "alice", false)
+
+	status, _ := doRequest(t, app, http.MethodPut, "/api/users/me", `{"password":"newpass123"}`, token)
+	require.Equal(t, http.StatusOK, status)
+
+	status, body := doRequest(t, app, http.MethodPost, "/api/users/login", fmt.Sprintf(`{"email":%q,"password":"newpass123"}`, user.Email), "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, body["token"])
+}
+
+func TestUpdateUserByIdAsAdmin(t *testing.T) {
+	app, db := newUserApp(t)
+	_, adminToken := testutil.CreateUser(t, db, "admin", true)
+	user, _ := testutil.CreateUser(t, db, "alice", false)
+
+	status, _ := doRequest(t, app, http.MethodPut, fmt.Sprintf("/api/users/%d", user.ID), `{"bio":"set by admin"}`, adminToken)
+	assert.Equal(t, http.StatusOK, status)
+
+	var updated models.User
+	require.NoError(t, db.First(&updated, user.ID).Error)
+	assert.Equal(t, "set by admin", updated.Bio)
+}
+
+func TestLastLoginVisibleToAdminsOnly(t *testing.T) {
+	app, db := newUserApp(t)
+	_, adminToken := testutil.CreateUser(t, db, "admin", true)
+	user, userToken := testutil.CreateUser(t, db, "alice", false)
+	require.NoError(t, db.Model(&user).UpdateColumn("last_login_at", time.Now()).Error)
+	path := fmt.Sprintf("/api/users/%d", user.ID)
+
+	for _, tc := range []struct {
+		name    string
+		token   string
+		visible bool
+	}{
+		{"anonymous", "", false},
+		{"other user", userToken, false},
+		{"admin", adminToken, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			status, body := doRequest(t, app, http.MethodGet, path, "", tc.token)
+			require.Equal(t, http.StatusOK, status)
+			_, ok := body["user"].(map[string]interface{})["lastLoginAt"]
+			assert.Equal(t, tc.visible, ok)
+
+			status, body = doRequest(t, app, http.MethodGet, "/api/users", "", tc.token)
+			require.Equal(t, http.StatusOK, status)
+			users := body["users"].([]interface{})
+			_, ok = users[1].(map[string]interface{})["lastLoginAt"]
+			assert.Equal(t, tc.visible, ok)
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	app, db := newUserApp(t)
+	user, accessToken := testutil.CreateUser(t, db, "alice", false)
+	verifyToken, err := utils.CreateVerificationToken(user.ID)
+	require.NoError(t, err)
+
+	// Verifying twice succeeds both times.
+	for i := 0; i < 2; i++ {
+		status, _ := doRequest(t, app, http.MethodGet, "/api/users/verify?token="+verifyToken, "", "")
+		assert.Equal(t, http.StatusOK, status)
+	}
+	var verified models.User
+	require.NoError(t, db.First(&verified, user.ID).Error)
+	assert.True(t, verified.IsEmailVerified)
+
+	t.Run("rejects access tokens", func(t *testing.T) {
+		status, body := doRequest(t, app, http.MethodGet, "/api/users/verify?token="+accessToken, "", "")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, utils.ErrCodeBadRequest, errorCode(body))
+	})
+	t.Run("rejects garbage", func(t *testing.T) {
+		status, _ := doRequest(t, app, http.MethodGet, "/api/users/verify?token=not-a-token", "", "")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+	t.Run("requires a token", func(t *testing.T) {
+		status, _ := doRequest(t, app, http.MethodGet, "/api/users/verify", "", "")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+	t.Run("verify tokens are not access tokens", func(t *testing.T) {
+		status, _ := doRequest(t, app, http.MethodGet, "/api/users/me", "", verifyToken)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
+
+func TestLoginRequiresVerifiedEmailWhenConfigured(t *testing.T) {
+	t.Setenv("REQUIRE_EMAIL_VERIFICATION", "true")
+	app, db := newUserApp(t)
+	user, _ := testutil.CreateUser(t, db, "alice", false)
+	credentials := fmt.Sprintf(`{"email":%q,"password":%q}`, user.Email, testutil.Password)
+
+	status, body := doRequest(t, app, http.MethodPost, "/api/users/login", credentials, "")
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, utils.ErrCodeForbidden, errorCode(body))
+
+	verifyToken, err := utils.CreateVerificationToken(user.ID)
+	require.NoError(t, err)
+	status, _ = doRequest(t, app, http.MethodGet, "/api/users/verify?token="+verifyToken, "", "")
+	require.Equal(t, http.StatusOK, status)
+
+	status, _ = doRequest(t, app, http.MethodPost, "/api/users/login", credentials, "")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestUpdateAndDeleteUserByIdRequireSelfOrAdmin(t *testing.T) {
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			app, db := newUserApp(t)
+			_, adminToken := testutil.CreateUser(t, db, "admin", true)
+			alice, _ := testutil.CreateUser(t, db, "alice", false)
+			bobby, bobbyToken := testutil.CreateUser(t, db, "bobby", false)
+			carol, _ := testutil.CreateUser(t, db, "carol", false)
+			path := func(user models.User) string { return fmt.Sprintf("/api/users/%d", user.ID) }
+			body := `{"bio":"changed"}`
+
+			status, response := doRequest(t, app, method, path(alice), body, bobbyToken)
+			assert.Equal(t, http.StatusForbidden, status, "other user")
+			assert.Equal(t, utils.ErrCodeForbidden, errorCode(response))
+
+			status, _ = doRequest(t, app, method, path(alice), body, "")
+			assert.Equal(t, http.StatusUnauthorized, status, "anonymous")
+
+			status, _ = doRequest(t, app, method, path(bobby), body, bobbyToken)
+			assert.Equal(t, http.StatusOK, status, "self")
+
+			status, _ = doRequest(t, app, method, path(carol), body, adminToken)
+			assert.Equal(t, http.StatusOK, status, "admin")
+
+			// Only the self and admin requests took effect.
+			var untouched models.User
+			require.NoError(t, db.First(&untouched, alice.ID).Error)
+			assert.Empty(t, untouched.Bio)
+		})
+	}
+}
+
+func TestDeleteMeEndToEnd(t *testing.T) {
+	app, _ := newUserApp(t)
+
+	status, _ := doRequest(t, app, http.MethodPost, "/api/users", `{"username":"alice","email":"alice@example.com","password":"password1"}`, "")
+	require.Equal(t, http.StatusCreated, status)
+
+	status, body := doRequest(t, app, http.MethodPost, "/api/users/login", `{"email":"alice@example.com","password":"password1"}`, "")
+	require.Equal(t, http.StatusOK, status)
+	token, _ := body["token"].(string)
+	require.NotEmpty(t, token)
+
+	status, body = doRequest(t, app, http.MethodDelete, "/api/users/me", "", token)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "User deleted successfully", body["message"])
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		status, body = doRequest(t, app, method, "/api/users/me", "", token)
+		assert.Equal(t, http.StatusForbidden, status, method)
+		assert.Equal(t, utils.ErrCodeForbidden, errorCode(body), method)
+	}
+	status, _ = doRequest(t, app, http.MethodPost, "/api/users/login", `{"email":"alice@example.com","password":"password1"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestUpdateMeToTakenEmailConflicts(t *testing.T) {
+	app, db := newUserApp(t)
+	testutil.CreateUser(t, db, "alice", false)
+	_, token := testutil.CreateUser(t, db, "bobby", false)
+
+	status, body := doRequest(t, app, http.MethodPut, "/api/users/me", `{"email":"alice@example.com"}`, token)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, utils.ErrCodeConflict, errorCode(body))
+}
+
+func TestGetDeletedUserRequiresAdmin(t *testing.T) {
+	app, db := newUserApp(t)
+	_, adminToken := testutil.CreateUser(t, db, "admin", true)
+	_, userToken := testutil.CreateUser(t, db, "alice", false)
+	deleted, _ := testutil.CreateUser(t, db, "bobby", false)
+	require.NoError(t, db.Delete(&deleted).Error)
+	path := fmt.Sprintf("/api/users/%d", deleted.ID)
+
+	status, body := doRequest(t, app, http.MethodGet, path+"?includeDeleted=true", "", "")
+	assert.Equal(t, http.StatusUnauthorized, status, "anonymous")
+	assert.Equal(t, utils.ErrCodeUnauthorized, errorCode(body))
+
+	status, body = doRequest(t, app, http.MethodGet, path+"?includeDeleted=true", "", userToken)
+	assert.Equal(t, http.StatusForbidden, status, "non-admin")
+	assert.Equal(t, utils.ErrCodeForbidden, errorCode(body))
+
+	status, body = doRequest(t, app, http.MethodGet, path+"?includeDeleted=true", "", adminToken)
+	require.Equal(t, http.StatusOK, status, "admin")
+	user, _ := body["user"].(map[string]interface{})
+	assert.Equal(t, float64(deleted.ID), user["id"])
+	assert.NotEmpty(t, user["deletedAt"])
+
+	// Without the flag, even an admin gets the scoped lookup.
+	for _, token := range []string{"", userToken, adminToken} {
+		status, body = doRequest(t, app, http.MethodGet, path, "", token)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, utils.ErrCodeUserNotFound, errorCode(body))
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"felix1234567890/go-trello/models"
+	"felix1234567890/go-trello/repository"
+	"felix1234567890/go-trello/testutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepo returns a repository backed by a private in-memory SQLite
+// database opened through the same connection code the server uses.
+func newTestRepo(t *testing.T) *repository.UserRepository {
+	t.Helper()
+	return repository.NewUserRepository(testutil.NewDB(t))
+}
+
+// seedUserCreatedAt stores a user with the given creation time.
+func seedUserCreatedAt(t *testing.T, repo *repository.UserRepository, username string, createdAt time.Time) {
+	t.Helper()
+	user := models.User{Username: username, Email: username + "@example.com"}
+	user.CreatedAt = createdAt
+	require.NoError(t, repo.DB.Create(&user).Error)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"felix1234567890/go-trello/models"
+	"felix1234567890/go-trello/repository"
+	"time"
+)
+
+type StatsService interface {
+	GetDailyRegistrations(ctx context.Context, days int) ([]models.DailyCount, error)
+	GetThisMonth(ctx context.Context) (models.PeriodCounts, error)
+	GetTotals(ctx context.Context) (models.Totals, error)
+}
+type StatsServiceImpl struct {
+	UserRepo *repository.UserRepository
+}
+
+func NewStatsService(userRepo *repository.UserRepository) *StatsServiceImpl {
+	return &StatsServiceImpl{
+		UserRepo: userRepo,
+	}
+}
+
+// GetDailyRegistrations returns one entry per day for the last days days,
+// ending today, with zero counts for days without registrations.
+func (s *StatsServiceImpl) GetDailyRegistrations(ctx context.Context, days int) ([]models.DailyCount, error) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day()-(days-1), 0, 0, 0, 0, now.Location())
+	counts, err := s.UserRepo.CountRegistrationsByDay(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	series := make([]models.DailyCount, days)
+	for i := range series {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		series[i] = models.DailyCount{Date: date, Count: counts[date]}
+	}
+	return series, nil
+}
+
+// GetThisMonth counts rows created since the start of the current calendar month.
+func (s *StatsServiceImpl) GetThisMonth(ctx context.Context) (models.PeriodCounts, error) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 1, 0)
+	newUsers, err := s.UserRepo.CountCreatedBetween(ctx, start, end)
+	if err != nil {
+		return models.PeriodCounts{}, err
+	}
+	return models.PeriodCounts{NewUsers: newUsers}, nil
+}
+
+// GetTotals returns the current number of live rows.
+func (s *StatsServiceImpl) GetTotals(ctx context.Context) (models.Totals, error) {
+	users, err := s.UserRepo.CountAll(ctx)
+	if err != nil {
+		return models.Totals{}, err
+	}
+	return models.Totals{Users: users}, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"felix1234567890/go-trello/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// daysAgo returns noon, local time, days days before today.
+func daysAgo(days int) time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()-days, 12, 0, 0, 0, now.Location())
+}
+
+func TestGetDailyRegistrations(t *testing.T) {
+	repo := newTestRepo(t)
+	seedUserCreatedAt(t, repo, "alice", daysAgo(0))
+	seedUserCreatedAt(t, repo, "bobby", daysAgo(0))
+	seedUserCreatedAt(t, repo, "carol", daysAgo(2))
+	// Outside the window.
+	seedUserCreatedAt(t, repo, "daisy", daysAgo(10))
+
+	series, err := NewStatsService(repo).GetDailyRegistrations(context.Background(), 4)
+	require.NoError(t, err)
+
+	day := func(days int) string { return daysAgo(days).Format("2006-01-02") }
+	assert.Equal(t, []models.DailyCount{
+		{Date: day(3), Count: 0},
+		{Date: day(2), Count: 1},
+		{Date: day(1), Count: 0},
+		{Date: day(0), Count: 2},
+	}, series)
+}
+
+func TestGetThisMonth(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	seedUserCreatedAt(t, repo, "alice", start)
+	seedUserCreatedAt(t, repo, "bobby", now)
+	// Outside the month.
+	seedUserCreatedAt(t, repo, "carol", start.Add(-time.Second))
+	seedUserCreatedAt(t, repo, "daisy", start.AddDate(0, 1, 0))
+
+	counts, err := NewStatsService(repo).GetThisMonth(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.PeriodCounts{NewUsers: 2}, counts)
+}
@@ -1,45 +1,106 @@
 package service
 
 import (
+	"context"
 	"felix1234567890/go-trello/models"
-	"felix1234567890/go-trello/repository"
+	"felix1234567890/go-trello/utils"
+	"log"
+	"os"
+	"strconv"
 )
 
 type UserService interface {
-	GetUsers() ([]models.User, error)
-	GetUserById(id string) (models.User, error)
-	DeleteUser(id string) error
-	UpdateUser(id string, req *models.UpdateUserRequest) error
-	CreateUser(req *models.User) (uint, error)
-	LoginUser(req *models.LoginUserRequest) (uint, error)
+	GetUsers(ctx context.Context) ([]models.User, error)
+	SearchUsers(ctx context.Context, q string, page, pageSize int) ([]models.User, int64, error)
+	GetUserById(ctx context.Context, id string, includeDeleted bool) (models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	DeleteUser(ctx context.Context, id string) error
+	UpdateUser(ctx context.Context, id string, req *models.UpdateUserRequest) error
+	CreateUser(ctx context.Context, req *models.User) (uint, error)
+	LoginUser(ctx context.Context, req *models.LoginUserRequest) (uint, error)
+	VerifyEmail(ctx context.Context, id uint) error
 }
+
+// UserRepository is the user storage UserServiceImpl depends on.
+// *repository.UserRepository implements it; tests can substitute a fake.
+type UserRepository interface {
+	GetUsers(ctx context.Context) ([]models.User, error)
+	SearchUsers(ctx context.Context, q string, page, pageSize int) ([]models.User, int64, error)
+	GetUserById(ctx context.Context, id string, includeDeleted bool) (models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	DeleteUser(ctx context.Context, id string) error
+	UpdateUser(ctx context.Context, id string, req *models.UpdateUserRequest) error
+	CreateUser(ctx context.Context, req *models.User) (uint, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+	Login(ctx context.Context, req *models.LoginUserRequest, requireVerifiedEmail bool) (uint, error)
+	VerifyEmail(ctx context.Context, id uint) error
+}
+
 type UserServiceImpl struct {
-	Repo *repository.UserRepository
+	Repo UserRepository
+	// RequireVerifiedEmail blocks logins until the user verifies their email.
+	RequireVerifiedEmail bool
 }
 
-func NewUserService(repo *repository.UserRepository) *UserServiceImpl {
+func NewUserService(repo UserRepository) *UserServiceImpl {
 	return &UserServiceImpl{
-		Repo: repo,
+		Repo:                 repo,
+		RequireVerifiedEmail: requireVerifiedEmail(),
+	}
+}
+
+// requireVerifiedEmail reads REQUIRE_EMAIL_VERIFICATION, which is off unless
+// set to a true value.
+func requireVerifiedEmail() bool {
+	value := os.Getenv("REQUIRE_EMAIL_VERIFICATION")
+	if value == "" {
+		return false
 	}
+	required, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Ignoring invalid REQUIRE_EMAIL_VERIFICATION %q", value)
+		return false
+	}
+	return required
 }
-func (s *UserServiceImpl) GetUsers() ([]models.User, error) {
-	return s.Repo.GetUsers()
+func (s *UserServiceImpl) GetUsers(ctx context.Context) ([]models.User, error) {
+	return s.Repo.GetUsers(ctx)
 }
 
-func (s *UserServiceImpl) GetUserById(id string) (models.User, error) {
-	return s.Repo.GetUserById(id)
+func (s *UserServiceImpl) SearchUsers(ctx context.Context, q string, page, pageSize int) ([]models.User, int64, error) {
+	return s.Repo.SearchUsers(ctx, q, page, pageSize)
 }
 
-func (s *UserServiceImpl) DeleteUser(id string) error {
-	return s.Repo.DeleteUser(id)
+func (s *UserServiceImpl) GetUserById(ctx context.Context, id string, includeDeleted bool) (models.User, error) {
+	return s.Repo.GetUserById(ctx, id, includeDeleted)
 }
-func (s *UserServiceImpl) UpdateUser(id string, req *models.UpdateUserRequest) error {
-	return s.Repo.UpdateUser(id, req)
+
+func (s *UserServiceImpl) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	return s.Repo.GetUserByEmail(ctx, email)
 }
-func (s *UserServiceImpl) CreateUser(req *models.User) (uint, error) {
-	return s.Repo.CreateUser(req)
+
+func (s *UserServiceImpl) DeleteUser(ctx context.Context, id string) error {
+	return s.Repo.DeleteUser(ctx, id)
+}
+func (s *UserServiceImpl) UpdateUser(ctx context.Context, id string, req *models.UpdateUserRequest) error {
+	return s.Repo.UpdateUser(ctx, id, req)
+}
+func (s *UserServiceImpl) CreateUser(ctx context.Context, req *models.User) (uint, error) {
+	req.Email = utils.NormalizeEmail(req.Email)
+	exists, err := s.Repo.EmailExists(ctx, req.Email)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, utils.ErrUserAlreadyExists
+	}
+	return s.Repo.CreateUser(ctx, req)
+}
+
+func (s *UserServiceImpl) LoginUser(ctx context.Context, LoginUserRequest *models.LoginUserRequest) (uint, error) {
+	return s.Repo.Login(ctx, LoginUserRequest, s.RequireVerifiedEmail)
 }
 
-func (s *UserServiceImpl) LoginUser(LoginUserRequest *models.LoginUserRequest) (uint, error) {
-	return s.Repo.Login(LoginUserRequest)
+func (s *UserServiceImpl) VerifyEmail(ctx context.Context, id uint) error {
+	return s.Repo.VerifyEmail(ctx, id)
 }
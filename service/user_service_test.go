@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"felix1234567890/go-trello/models"
+	"felix1234567890/go-trello/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUserRepository stores users by email. Methods the tests don't need
+// are left to the embedded nil interface and panic if called.
+type fakeUserRepository struct {
+	UserRepository
+	users map[string]*models.User
+	// checked records the emails passed to EmailExists.
+	checked []string
+}
+
+func newFakeUserRepository(emails ...string) *fakeUserRepository {
+	repo := &fakeUserRepository{users: make(map[string]*models.User)}
+	for _, email := range emails {
+		repo.users[email] = &models.User{Email: email}
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	r.checked = append(r.checked, email)
+	_, ok := r.users[email]
+	return ok, nil
+}
+
+func (r *fakeUserRepository) CreateUser(ctx context.Context, req *models.User) (uint, error) {
+	req.ID = uint(len(r.users) + 1)
+	r.users[req.Email] = req
+	return req.ID, nil
+}
+
+func TestCreateUserRejectsExistingEmail(t *testing.T) {
+	repo := newFakeUserRepository("alice@example.com")
+
+	_, err := NewUserService(repo).CreateUser(context.Background(), &models.User{Username: "alice2", Email: "  ALICE@example.com ", Password: "password1"})
+	assert.ErrorIs(t, err, utils.ErrUserAlreadyExists)
+	assert.Equal(t, []string{"alice@example.com"}, repo.checked)
+	assert.Len(t, repo.users, 1)
+}
+
+func TestCreateUserStoresNewEmail(t *testing.T) {
+	repo := newFakeUserRepository("bob@example.com")
+
+	id, err := NewUserService(repo).CreateUser(context.Background(), &models.User{Username: "alice", Email: "  Alice@Example.com ", Password: "password1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com"}, repo.checked)
+	require.Contains(t, repo.users, "alice@example.com")
+	assert.Equal(t, id, repo.users["alice@example.com"].ID)
+}
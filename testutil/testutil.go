@@ -0,0 +1,82 @@
+// Package testutil holds the fixtures shared by the tests of several
+// packages: an in-memory database, seeded users and their tokens.
+package testutil
+
+import (
+	"felix1234567890/go-trello/database"
+	"felix1234567890/go-trello/models"
+	"felix1234567890/go-trello/utils"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Password is the plain-text password of every user made by CreateUser.
+const Password = "password1"
+
+// Run sets a fixed token signing key, runs the tests and exits. Call it from
+// TestMain.
+func Run(m *testing.M) {
+	utils.SECRET_KEY = []byte("test-secret")
+	os.Exit(m.Run())
+}
+
+// NewDB opens a private in-memory SQLite database through the same
+// connection code the server uses, migrates it and closes it when the test
+// ends.
+func NewDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	t.Setenv("DB_DRIVER", database.DriverSQLite)
+	t.Setenv("SQLITE_PATH", "file:"+strings.ReplaceAll(t.Name(), "/", "_")+"?mode=memory&cache=shared")
+	db, err := database.ConnectWithRetry(1, 0)
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}))
+	t.Cleanup(func() {
+		if connection, err := db.DB(); err == nil {
+			connection.Close()
+		}
+	})
+	return db
+}
+
+// UseDB is NewDB for code that reads database.DB: it points the global at the
+// new database until the test ends.
+func UseDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := NewDB(t)
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+	return db
+}
+
+// CreateUser stores a user named username whose password is Password and
+// returns it with an access token.
+func CreateUser(t *testing.T, db *gorm.DB, username string, isAdmin bool) (models.User, string) {
+	t.Helper()
+	hashedPassword, err := utils.HashPassword(Password)
+	require.NoError(t, err)
+	user := models.User{Username: username, Email: username + "@example.com", Password: hashedPassword, IsAdmin: isAdmin}
+	require.NoError(t, db.Create(&user).Error)
+	token, err := utils.CreateToken(user.ID)
+	require.NoError(t, err)
+	return user, token
+}
+
+// ExpiredToken signs an access token for id that expired a minute ago.
+func ExpiredToken(t *testing.T, id uint) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, utils.AppClaims{
+		UserID: id,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString(utils.SECRET_KEY)
+	require.NoError(t, err)
+	return token
+}
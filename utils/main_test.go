@@ -0,0 +1,11 @@
+package utils
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	SECRET_KEY = []byte("test-secret")
+	os.Exit(m.Run())
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ParsePagination reads the page and pageSize query parameters, falling back
+// to the first page and DefaultPageSize, and clamping pageSize to MaxPageSize.
+func ParsePagination(c *fiber.Ctx) (page, pageSize int) {
+	page = c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	pageSize = c.QueryInt("pageSize", DefaultPageSize)
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return page, pageSize
+}
+
+// TotalPages returns how many pages of pageSize items are needed for total items.
+func TotalPages(total int64, pageSize int) int {
+	if pageSize < 1 {
+		return 0
+	}
+	return int((total + int64(pageSize) - 1) / int64(pageSize))
+}
+
+// Paginate wraps a page of items in the standard paginated envelope.
+func Paginate[T any](items []T, total int64, page, pageSize int) fiber.Map {
+	if items == nil {
+		items = []T{}
+	}
+	return fiber.Map{
+		"data":       items,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": TotalPages(total, pageSize),
+	}
+}
+
+// PaginationLinks builds next/prev links for a paginated response from the
+// current request path and query string, replacing only the page parameter.
+// next is omitted on the last page and prev on the first.
+func PaginationLinks(c *fiber.Ctx, page, totalPages int) fiber.Map {
+	links := fiber.Map{}
+	if page < totalPages {
+		links["next"] = pageURL(c, page+1)
+	}
+	if page > 1 {
+		links["prev"] = pageURL(c, page-1)
+	}
+	return links
+}
+
+func pageURL(c *fiber.Ctx, page int) string {
+	query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		query = url.Values{}
+	}
+	query.Set("page", strconv.Itoa(page))
+	return c.Path() + "?" + query.Encode()
+}
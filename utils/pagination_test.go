@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handle runs handler for a GET of target and decodes its JSON response.
+func handle(t *testing.T, target string, handler fiber.Handler) map[string]interface{} {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/items", handler)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body
+}
+
+func TestPaginationLinks(t *testing.T) {
+	links := func(target string, page int) map[string]interface{} {
+		return handle(t, target, func(c *fiber.Ctx) error {
+			return c.JSON(PaginationLinks(c, page, 3))
+		})
+	}
+
+	first := links("/items?page=1&q=go", 1)
+	assert.Equal(t, "/items?page=2&q=go", first["next"])
+	assert.NotContains(t, first, "prev")
+
+	middle := links("/items?page=2&q=go", 2)
+	assert.Equal(t, "/items?page=3&q=go", middle["next"])
+	assert.Equal(t, "/items?page=1&q=go", middle["prev"])
+
+	last := links("/items?page=3&q=go", 3)
+	assert.NotContains(t, last, "next")
+	assert.Equal(t, "/items?page=2&q=go", last["prev"])
+}
+
+func TestParsePagination(t *testing.T) {
+	for target, want := range map[string][2]float64{
+		"/items":                      {1, DefaultPageSize},
+		"/items?page=3&pageSize=10":   {3, 10},
+		"/items?page=0&pageSize=0":    {1, DefaultPageSize},
+		"/items?page=-2&pageSize=-5":  {1, DefaultPageSize},
+		"/items?pageSize=1000":        {1, MaxPageSize},
+		"/items?page=abc&pageSize=xy": {1, DefaultPageSize},
+	} {
+		body := handle(t, target, func(c *fiber.Ctx) error {
+			page, pageSize := ParsePagination(c)
+			return c.JSON(fiber.Map{"page": page, "pageSize": pageSize})
+		})
+		assert.Equal(t, want[0], body["page"], target)
+		assert.Equal(t, want[1], body["pageSize"], target)
+	}
+}
+
+func TestTotalPages(t *testing.T) {
+	assert.Equal(t, 0, TotalPages(0, 20))
+	assert.Equal(t, 1, TotalPages(1, 20))
+	assert.Equal(t, 1, TotalPages(20, 20))
+	assert.Equal(t, 2, TotalPages(21, 20))
+	assert.Equal(t, 0, TotalPages(10, 0))
+}
+
+func TestPaginateEnvelope(t *testing.T) {
+	envelope := Paginate([]string(nil), 45, 2, 20)
+
+	assert.Equal(t, []string{}, envelope["data"])
+	assert.Equal(t, int64(45), envelope["total"])
+	assert.Equal(t, 2, envelope["page"])
+	assert.Equal(t, 20, envelope["pageSize"])
+	assert.Equal(t, 3, envelope["totalPages"])
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signWith signs an access token for id with method and key.
+func signWith(t *testing.T, method jwt.SigningMethod, key interface{}, id uint) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, AppClaims{
+		UserID: id,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestParseTokenAcceptsCreateToken(t *testing.T) {
+	token, err := CreateToken(7)
+	require.NoError(t, err)
+
+	claims, err := ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+}
+
+func TestParseTokenRejectsOtherSigningMethods(t *testing.T) {
+	for name, token := range map[string]string{
+		"HS512": signWith(t, jwt.SigningMethodHS512, SECRET_KEY, 7),
+		"none":  signWith(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, 7),
+	} {
+		_, err := ParseToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid, name)
+	}
+}
+
+func TestParseTokenRejectsWrongSecret(t *testing.T) {
+	_, err := ParseToken(signWith(t, jwt.SigningMethodHS256, []byte("other-secret"), 7))
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+}
+
+func TestTokenUserIDRoundTrip(t *testing.T) {
+	// Large enough that a float64 round trip or string coercion would show.
+	const id uint = 1<<53 + 1
+	token, err := CreateToken(id)
+	require.NoError(t, err)
+
+	claims, err := ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, id, claims.UserID)
+
+	extracted, err := ExtractIDFromToken(token, string(SECRET_KEY))
+	require.NoError(t, err)
+	assert.Equal(t, id, extracted)
+}
+
+func TestVerificationTokenIsNotAnAccessToken(t *testing.T) {
+	token, err := CreateVerificationToken(7)
+	require.NoError(t, err)
+
+	_, err = ParseToken(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidClaims)
+	authorized, err := IsAuthorized(token, string(SECRET_KEY))
+	assert.False(t, authorized)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidClaims)
+	_, err = ExtractIDFromToken(token, string(SECRET_KEY))
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidClaims)
+}
@@ -5,9 +5,15 @@ import (
 	"felix1234567890/go-trello/database"
 	"felix1234567890/go-trello/models"
 	"fmt"
+	"log"
 	"math/rand"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-faker/faker/v4"
 	"github.com/go-playground/validator"
@@ -18,6 +24,25 @@ import (
 
 var SECRET_KEY = []byte(os.Getenv("SECRET_KEY"))
 
+// ErrInvalidCredentials is returned when a login email is unknown or the
+// password does not match. Both cases share one error to avoid leaking
+// which emails are registered.
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrUserAlreadyExists is returned when creating a user whose email is taken.
+var ErrUserAlreadyExists = errors.New("email already in use")
+
+// ErrEmailNotVerified is returned when logging in before confirming the
+// account's email address while verification is required.
+var ErrEmailNotVerified = errors.New("email address has not been verified")
+
+// MaxPasswordBytes is the longest password bcrypt takes into account; any
+// bytes past it would be silently ignored.
+const MaxPasswordBytes = 72
+
+// ErrPasswordTooLong is returned for passwords longer than MaxPasswordBytes.
+var ErrPasswordTooLong = errors.New("password must not exceed 72 bytes")
+
 func FakeUserFactory() {
 	min := 5
 	max := 10
@@ -33,29 +58,177 @@ func FakeUserFactory() {
 	}
 }
 
-func ValidateRequest(data interface{}) []error {
-	validate := validator.New()
+// ValidateRequest validates data and returns a message per failed field,
+// keyed by the field's JSON name so clients can match errors to inputs.
+func ValidateRequest(data interface{}) map[string]string {
 	err := validate.Struct(data)
+	if err == nil {
+		return nil
+	}
+	fieldErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		// data is nil or not a struct, as when a client sends a JSON null.
+		return map[string]string{"body": "request body must be a JSON object"}
+	}
+	validationErrors := make(map[string]string)
+	for _, e := range fieldErrors {
+		// The value is left out so rejected passwords are never echoed back.
+		validationErrors[e.Field()] = fmt.Sprintf("'%s' does not satisfy '%s'", e.Field(), e.Tag())
+	}
+	return validationErrors
+}
+
+// validate is shared by every request; building a validator is costly since
+// it caches struct metadata, and a *validator.Validate is safe for concurrent
+// use.
+var validate = newValidator()
+
+// newValidator returns a validator that reports fields by their json tag
+// rather than their Go name.
+func newValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	validate.RegisterValidation("strongpassword", isStrongPassword)
+	validate.RegisterValidation("maxbytes", isWithinMaxBytes)
+	return validate
+}
+
+// isWithinMaxBytes implements the maxbytes tag. Unlike max, which counts
+// runes, it limits the UTF-8 encoded length, which is what bcrypt sees.
+func isWithinMaxBytes(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
 	if err != nil {
-		var validationErrors []error
-		for _, e := range err.(validator.ValidationErrors) {
-			errMsg := fmt.Sprintf("'%s' has a value of '%v' which does not satisfy '%s' constraint", e.Field(), e.Value(), e.Tag())
-			validationErrors = append(validationErrors, errors.New(errMsg))
+		return false
+	}
+	return len(fl.Field().String()) <= limit
+}
+
+// MinPasswordLength is the shortest password strongpassword accepts when the
+// tag has no parameter; "strongpassword=12" raises it to 12.
+const MinPasswordLength = 8
+
+// isStrongPassword implements the strongpassword tag: the value must reach
+// the minimum length and contain at least one letter and one digit.
+func isStrongPassword(fl validator.FieldLevel) bool {
+	minLength := MinPasswordLength
+	if param := fl.Param(); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return false
 		}
-		return validationErrors
+		minLength = n
+	}
+	password := fl.Field().String()
+	if utf8.RuneCountInString(password) < minLength {
+		return false
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		hasDigit = hasDigit || unicode.IsDigit(r)
 	}
-	return nil
+	return hasLetter && hasDigit
 }
 
+// Error codes returned in the "code" field of error responses so clients can
+// tell failures apart without parsing messages.
+const (
+	ErrCodeValidation   = "VALIDATION_ERROR"
+	ErrCodeInvalidBody  = "INVALID_BODY"
+	ErrCodeBadRequest   = "BAD_REQUEST"
+	ErrCodeUnauthorized = "UNAUTHORIZED"
+	ErrCodeTokenExpired = "TOKEN_EXPIRED"
+	ErrCodeForbidden    = "FORBIDDEN"
+	ErrCodeNotFound     = "NOT_FOUND"
+	ErrCodeUserNotFound = "USER_NOT_FOUND"
+	ErrCodeConflict     = "CONFLICT"
+	ErrCodeInternal     = "INTERNAL_ERROR"
+)
+
+// HandleErrorResponse writes an error response with a code derived from the
+// HTTP status. Prefer HandleErrorResponseWithCode when a more specific code
+// applies.
 func HandleErrorResponse(c *fiber.Ctx, status int, message string) error {
-	return c.Status(status).JSON(fiber.Map{"message": message})
+	return HandleErrorResponseWithCode(c, status, errorCodeForStatus(status), message)
+}
+
+// HandleErrorResponseWithCode writes {"error": {"code": code, "message": message}}.
+func HandleErrorResponseWithCode(c *fiber.Ctx, status int, code string, message string) error {
+	return c.Status(status).JSON(fiber.Map{
+		"error": errorBody(c, code, message),
+	})
+}
+
+// HandleInternalError logs err with the request it failed and writes a
+// generic 500 response, so database and driver errors never reach clients.
+func HandleInternalError(c *fiber.Ctx, err error) error {
+	requestID, _ := c.Locals("request_id").(string)
+	log.Printf("%s %s failed (request %s): %s", c.Method(), c.Path(), requestID, err)
+	return HandleErrorResponseWithCode(c, fiber.StatusInternalServerError, ErrCodeInternal, "internal server error")
+}
+
+// HandleValidationErrors writes a 400 validation error response that also
+// maps each failed field to its message under "errors".
+func HandleValidationErrors(c *fiber.Ctx, validationErrors map[string]string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error":  errorBody(c, ErrCodeValidation, "Validation failed"),
+		"errors": validationErrors,
+	})
+}
+
+// errorBody builds the "error" object of an error response, including the
+// request ID set by the RequestID middleware when there is one.
+func errorBody(c *fiber.Ctx, code string, message string) fiber.Map {
+	body := fiber.Map{
+		"code":    code,
+		"message": message,
+	}
+	if requestID, ok := c.Locals("request_id").(string); ok {
+		body["request_id"] = requestID
+	}
+	return body
+}
+
+func errorCodeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return ErrCodeBadRequest
+	case fiber.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case fiber.StatusForbidden:
+		return ErrCodeForbidden
+	case fiber.StatusNotFound:
+		return ErrCodeNotFound
+	case fiber.StatusConflict:
+		return ErrCodeConflict
+	case fiber.StatusUnprocessableEntity:
+		return ErrCodeValidation
+	default:
+		return ErrCodeInternal
+	}
 }
 
 func JsonResponse(c *fiber.Ctx, status int, data interface{}) error {
 	return c.Status(status).JSON(data)
 }
 
+// NormalizeEmail trims and lowercases an email address so lookups and the
+// unique index treat addresses that differ only in case or surrounding
+// whitespace as the same.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 func HashPassword(password string) (string, error) {
+	if len(password) > MaxPasswordBytes {
+		return "", ErrPasswordTooLong
+	}
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return "", err
@@ -64,15 +237,51 @@ func HashPassword(password string) (string, error) {
 }
 
 func CheckPasswordHash(password, hash string) error {
+	if len(password) > MaxPasswordBytes {
+		return ErrPasswordTooLong
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err
 }
 
+// TokenTypeVerify marks tokens issued by CreateVerificationToken. Access
+// tokens leave Type empty.
+const TokenTypeVerify = "verify"
+
+// verificationTokenTTL is how long an emailed verification link stays valid.
+const verificationTokenTTL = 24 * time.Hour
+
+// AppClaims are the JWT claims issued by CreateToken and checked by the
+// auth middlewares.
+type AppClaims struct {
+	UserID uint   `json:"id"`
+	Type   string `json:"type,omitempty"`
+	jwt.RegisteredClaims
+}
+
 func CreateToken(id uint) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":  id,
-		"exp": time.Now().Add(time.Hour * 1).Unix(),
+	return signToken(AppClaims{
+		UserID: id,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 1)),
+		},
 	})
+}
+
+// CreateVerificationToken issues a token that confirms the email address of
+// user id. It cannot be used as an access token.
+func CreateVerificationToken(id uint) (string, error) {
+	return signToken(AppClaims{
+		UserID: id,
+		Type:   TokenTypeVerify,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(verificationTokenTTL)),
+		},
+	})
+}
+
+func signToken(claims AppClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(SECRET_KEY)
 	if err != nil {
 		return "", err
@@ -80,36 +289,68 @@ func CreateToken(id uint) (string, error) {
 	return tokenString, nil
 }
 
-func IsAuthorized(requestToken string, secret string) (bool, error) {
-	_, err := jwt.Parse(requestToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+// ParseToken validates an access token issued by CreateToken and returns its
+// claims. Only HS256 signatures are accepted; an expired token yields an
+// error matching jwt.ErrTokenExpired.
+func ParseToken(tokenString string) (*AppClaims, error) {
+	return parseAccessToken(tokenString, SECRET_KEY)
+}
+
+// ParseVerificationToken validates a token issued by CreateVerificationToken
+// and returns the ID of the user whose email it confirms.
+func ParseVerificationToken(tokenString string) (uint, error) {
+	claims, err := parseToken(tokenString, SECRET_KEY)
 	if err != nil {
-		return false, err
+		return 0, err
 	}
-	return true, nil
+	if claims.Type != TokenTypeVerify {
+		return 0, jwt.ErrTokenInvalidClaims
+	}
+	return claims.UserID, nil
 }
 
-func ExtractIDFromToken(requestToken string, secret string) (string, error) {
-	token, err := jwt.Parse(requestToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
-
+func parseToken(tokenString string, secret []byte) (*AppClaims, error) {
+	var claims AppClaims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
 	}
+	return &claims, nil
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
+// parseAccessToken is parseToken for access tokens only; tokens issued for
+// another purpose, such as email verification, are rejected.
+func parseAccessToken(tokenString string, secret []byte) (*AppClaims, error) {
+	claims, err := parseToken(tokenString, secret)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != "" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
 
-	if !ok && !token.Valid {
-		return "", fmt.Errorf("Invalid Token")
+// IsAuthorized reports whether requestToken is a valid access token signed
+// with secret.
+func IsAuthorized(requestToken string, secret string) (bool, error) {
+	if _, err := parseAccessToken(requestToken, []byte(secret)); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	return claims["id"].(string), nil
+// ExtractIDFromToken returns the user ID carried by an access token signed
+// with secret.
+func ExtractIDFromToken(requestToken string, secret string) (uint, error) {
+	claims, err := parseAccessToken(requestToken, []byte(secret))
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
 }
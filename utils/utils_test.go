@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"felix1234567890/go-trello/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// passwordOfBytes returns a password that satisfies strongpassword and is
+// exactly n bytes long.
+func passwordOfBytes(n int) string {
+	return "a1" + strings.Repeat("x", n-2)
+}
+
+func validCreateUserRequest(password string) *models.CreateUserRequest {
+	return &models.CreateUserRequest{Username: "alice", Email: "alice@example.com", Password: password}
+}
+
+func TestPasswordByteLimit(t *testing.T) {
+	assert.Empty(t, ValidateRequest(validCreateUserRequest(passwordOfBytes(MaxPasswordBytes))))
+	assert.Contains(t, ValidateRequest(validCreateUserRequest(passwordOfBytes(MaxPasswordBytes+1))), "password")
+}
+
+func TestPasswordByteLimitCountsBytesNotRunes(t *testing.T) {
+	// 62 runes but 122 bytes.
+	password := "a1" + strings.Repeat("é", 60)
+
+	assert.Contains(t, ValidateRequest(validCreateUserRequest(password)), "password")
+	assert.Contains(t, ValidateRequest(&models.UpdateUserRequest{Password: password}), "password")
+}
+
+func TestHashPasswordByteLimit(t *testing.T) {
+	hash, err := HashPassword(passwordOfBytes(MaxPasswordBytes))
+	require.NoError(t, err)
+	assert.NoError(t, CheckPasswordHash(passwordOfBytes(MaxPasswordBytes), hash))
+
+	_, err = HashPassword(passwordOfBytes(MaxPasswordBytes + 1))
+	assert.ErrorIs(t, err, ErrPasswordTooLong)
+}
+
+func TestPasswordsDifferingPastByteLimitAreRejected(t *testing.T) {
+	// bcrypt would treat these as the same password, so neither may be used.
+	prefix := passwordOfBytes(MaxPasswordBytes)
+	for _, password := range []string{prefix + "A", prefix + "B"} {
+		assert.Contains(t, ValidateRequest(validCreateUserRequest(password)), "password")
+		_, err := HashPassword(password)
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+	}
+
+	hash, err := HashPassword(prefix)
+	require.NoError(t, err)
+	assert.ErrorIs(t, CheckPasswordHash(prefix+"A", hash), ErrPasswordTooLong)
+}
+
+func TestUserFieldBounds(t *testing.T) {
+	// emailOfLength returns a valid address exactly n bytes long, n >= 197.
+	emailOfLength := func(n int) string {
+		domain := strings.Repeat("x", 63) + "." + strings.Repeat("y", 63) + "." + strings.Repeat("z", 63) + ".com"
+		return strings.Repeat("a", n-len(domain)-1) + "@" + domain
+	}
+	tests := []struct {
+		name    string
+		request interface{}
+		invalid string
+	}{
+		{"username at minimum", &models.CreateUserRequest{Username: "alice", Email: "alice@example.com", Password: "password1"}, ""},
+		{"username too short", &models.CreateUserRequest{Username: "alic", Email: "alice@example.com", Password: "password1"}, "username"},
+		{"username at maximum", &models.CreateUserRequest{Username: strings.Repeat("a", 50), Email: "alice@example.com", Password: "password1"}, ""},
+		{"username too long", &models.CreateUserRequest{Username: strings.Repeat("a", 51), Email: "alice@example.com", Password: "password1"}, "username"},
+		{"email at maximum", &models.CreateUserRequest{Username: "alice", Email: emailOfLength(254), Password: "password1"}, ""},
+		{"email too long", &models.CreateUserRequest{Username: "alice", Email: emailOfLength(255), Password: "password1"}, "email"},
+		{"update username too long", &models.UpdateUserRequest{Username: strings.Repeat("a", 51)}, "username"},
+		{"update email too long", &models.UpdateUserRequest{Email: emailOfLength(255)}, "email"},
+		{"bio at maximum", &models.UpdateUserRequest{Bio: strings.Repeat("b", 500)}, ""},
+		{"bio too long", &models.UpdateUserRequest{Bio: strings.Repeat("b", 501)}, "bio"},
+		{"avatar url", &models.UpdateUserRequest{AvatarURL: "https://example.com/a.png"}, ""},
+		{"avatar not a url", &models.UpdateUserRequest{AvatarURL: "not a url"}, "avatarUrl"},
+		{"empty update", &models.UpdateUserRequest{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := ValidateRequest(tt.request)
+			if tt.invalid == "" {
+				assert.Empty(t, errors)
+				return
+			}
+			assert.Contains(t, errors, tt.invalid)
+			assert.Len(t, errors, 1)
+		})
+	}
+}
+
+// errorFields returns the field names of a validation error map.
+func errorFields(errors map[string]string) []string {
+	fields := make([]string, 0, len(errors))
+	for field := range errors {
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func TestValidationErrorsUseJSONKeys(t *testing.T) {
+	errors := ValidateRequest(&models.CreateUserRequest{Email: "not-an-email", Password: "password1"})
+	assert.ElementsMatch(t, []string{"username", "email"}, errorFields(errors))
+	assert.Contains(t, errors["email"], "'email'")
+
+	errors = ValidateRequest(&models.UpdateUserRequest{AvatarURL: "not a url"})
+	assert.Equal(t, []string{"avatarUrl"}, errorFields(errors))
+}
+
+// BenchmarkValidateRequest and BenchmarkValidateRequestNewValidator compare
+// the shared validator with building one per call, as ValidateRequest used
+// to; run with -benchmem to see the difference in allocations.
+func BenchmarkValidateRequest(b *testing.B) {
+	request := validCreateUserRequest("password1")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateRequest(request)
+	}
+}
+
+func BenchmarkValidateRequestNewValidator(b *testing.B) {
+	request := validCreateUserRequest("password1")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		newValidator().Struct(request)
+	}
+}
+
+func TestStrongPassword(t *testing.T) {
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{"password1", true},
+		{"12345678a", true},
+		{"pässwört1", true},
+		{"passwordd", false}, // no digit
+		{"123456789", false}, // no letter
+		{"pass1", false},     // too short
+		{"passwo1", false},   // one short of MinPasswordLength
+		{"passwor1", true},   // exactly MinPasswordLength
+	}
+	for _, tt := range tests {
+		errors := ValidateRequest(validCreateUserRequest(tt.password))
+		if tt.valid {
+			assert.Empty(t, errors, tt.password)
+		} else {
+			assert.Contains(t, errors, "password", tt.password)
+		}
+	}
+}
+
+func TestStrongPasswordMinimumLengthParam(t *testing.T) {
+	type request struct {
+		Password string `json:"password" validate:"strongpassword=12"`
+	}
+
+	assert.Contains(t, ValidateRequest(&request{Password: "password1"}), "password")
+	assert.Empty(t, ValidateRequest(&request{Password: "password1234"}))
+}
+
+func TestValidationErrorsDoNotEchoValues(t *testing.T) {
+	password := "secretpassword"
+	errors := ValidateRequest(validCreateUserRequest(password))
+
+	assert.Equal(t, "'password' does not satisfy 'strongpassword'", errors["password"])
+	for _, message := range errors {
+		assert.NotContains(t, message, password)
+	}
+}
+
+func TestValidateRequestRejectsNonStruct(t *testing.T) {
+	var request *models.CreateUserRequest
+	for _, data := range []interface{}{nil, request, "not a struct"} {
+		assert.Equal(t, map[string]string{"body": "request body must be a JSON object"}, ValidateRequest(data))
+	}
+}